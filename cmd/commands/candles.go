@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core"
+)
+
+func BuildCandles() *cli.Command {
+	return &cli.Command{
+		Name:      "candles",
+		Usage:     "Builds OHLCV candles for a token from its Uniswap V2 swaps",
+		ArgsUsage: "<token-address>",
+		Flags: []cli.Flag{
+			&cli.Int64Flag{
+				Name:  "num-blocks",
+				Usage: "Number of blocks to search for swaps",
+				Value: 1000,
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "Candle interval",
+				Value: 5 * time.Minute,
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format (csv or json)",
+				Value: "csv",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file, stdout if not set",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if !common.IsHexAddress(ctx.Args().First()) {
+				return cli.Exit("Expected a token address", 1)
+			}
+			tokenAddress := common.HexToAddress(ctx.Args().First())
+			if ctx.Duration("interval") <= 0 {
+				return cli.Exit("Expected a positive --interval", 1)
+			}
+
+			conf, err := config.LoadConfig()
+			if err != nil {
+				panic("Failed to load config:\n\n\t" + err.Error())
+			}
+			candles, err := core.GenerateCandles(conf.EthNodeURL, tokenAddress, uint64(ctx.Int64("num-blocks")), ctx.Duration("interval"))
+			if err != nil {
+				panic("Failed to generate candles:\n\n\t" + err.Error())
+			}
+			err = core.ExportCandles(candles, ctx.String("format"), ctx.String("output"))
+			if err != nil {
+				panic("Failed to export candles:\n\n\t" + err.Error())
+			}
+			return nil
+		},
+	}
+}
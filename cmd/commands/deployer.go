@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+func Deployer() *cli.Command {
+	return &cli.Command{
+		Name:      "deployer",
+		Usage:     "Shows the contracts an address has deployed and which of its tokens were rugged",
+		ArgsUsage: "<address>",
+		Flags: []cli.Flag{
+			&cli.Uint64Flag{
+				Name:  "block",
+				Usage: "Report the history up to this block, latest if not set",
+			},
+			&cli.IntFlag{
+				Name:  "funding-hops",
+				Usage: "Maximum number of hops to trace the deployer's funding back",
+				Value: 3,
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format (text or json)",
+				Value: "text",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file for json, stdout if not set",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if !common.IsHexAddress(ctx.Args().First()) {
+				return cli.Exit("Expected a deployer address", 1)
+			}
+			deployer := common.HexToAddress(ctx.Args().First())
+
+			conf, err := config.LoadConfig()
+			if err != nil {
+				panic("Failed to load config:\n\n\t" + err.Error())
+			}
+			addressLabels, err := labels.Load(conf.LabelsFile)
+			if err != nil {
+				panic("Failed to load address labels:\n\n\t" + err.Error())
+			}
+			report, err := core.GenerateDeployerReport(conf.EthNodeURL, deployer, ctx.Uint64("block"), ctx.Int("funding-hops"), addressLabels)
+			if err != nil {
+				panic("Failed to generate deployer report:\n\n\t" + err.Error())
+			}
+
+			if ctx.String("format") == "json" {
+				err = utils.WriteJSON(ctx.String("output"), report)
+				if err != nil {
+					panic("Failed to export deployer report:\n\n\t" + err.Error())
+				}
+				return nil
+			}
+			core.PrintDeployerReport(report)
+			return nil
+		},
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/zachmdsi/go-token-cli/internal/config"
 	"github.com/zachmdsi/go-token-cli/internal/core"
 	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
 )
 
 func GenerateProfiles() *cli.Command {
@@ -18,25 +19,80 @@ func GenerateProfiles() *cli.Command {
 				Usage: "Number of blocks to search for created contracts",
 				Value: 1000,
 			},
+			&cli.BoolFlag{
+				Name:  "local-sim",
+				Usage: "Run trade simulations in an in-process EVM instead of eth_call state overrides",
+			},
+			&cli.IntFlag{
+				Name:  "funding-hops",
+				Usage: "Maximum number of hops to trace each deployer's funding back",
+				Value: 3,
+			},
+			&cli.Uint64Flag{
+				Name:  "sniper-blocks",
+				Usage: "Number of blocks after launch to look for snipers in",
+				Value: 5,
+			},
+			&cli.StringFlag{
+				Name:  "sort",
+				Usage: "Sort profiles by \"risk\" (highest first), discovery order if not set",
+			},
+			&cli.Float64Flag{
+				Name:  "max-risk",
+				Usage: "Skip tokens with a risk score above this",
+				Value: 100,
+			},
+			&cli.Float64Flag{
+				Name:  "max-top10-share",
+				Usage: "Skip tokens whose 10 largest holders hold more than this percentage of the held supply",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format (text or json)",
+				Value: "text",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file for json, stdout if not set",
+			},
 		},
 		Action: func(ctx *cli.Context) error {
+			if sortBy := ctx.String("sort"); sortBy != "" && sortBy != "risk" {
+				return cli.Exit("Expected --sort risk", 1)
+			}
 			conf, err := config.LoadConfig()
 			if err != nil {
 				panic("Failed to load config:\n\n\t" + err.Error())
 			}
 			numBlocks := uint64(ctx.Int64("num-blocks"))
-			txs, err := contracts.FindCreatedContracts(conf.EthNodeURL, numBlocks)
+			creations, err := contracts.FindCreatedContracts(conf.EthNodeURL, numBlocks)
 			if err != nil {
 				panic("Failed to create contracts:\n\n\t" + err.Error())
 			}
-			erc20Addresses, err := contracts.FindERC20Tokens(conf.EthNodeURL, txs)
+			erc20Creations, err := contracts.FindERC20Tokens(conf.EthNodeURL, creations)
 			if err != nil {
 				panic("Failed to find ERC20 tokens:\n\n\t" + err.Error())
 			}
-			_, err = core.GenerateTokenProfiles(conf.EthNodeURL, numBlocks, erc20Addresses)
+			tokens, err := core.GenerateTokenProfiles(conf, numBlocks, erc20Creations, core.ProfileOptions{
+				LocalSimulation: ctx.Bool("local-sim"),
+				MaxTop10Share:   ctx.Float64("max-top10-share"),
+				FundingHops:     ctx.Int("funding-hops"),
+				SniperBlocks:    ctx.Uint64("sniper-blocks"),
+				MaxRisk:         ctx.Float64("max-risk"),
+				SortBy:          ctx.String("sort"),
+			})
 			if err != nil {
 				panic("Failed to generate token profiles:\n\n\t" + err.Error())
 			}
+
+			if ctx.String("format") == "json" {
+				err = utils.WriteJSON(ctx.String("output"), tokens)
+				if err != nil {
+					panic("Failed to export token profiles:\n\n\t" + err.Error())
+				}
+				return nil
+			}
+			core.PrintTokenProfiles(tokens)
 			return nil
 		},
 	}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+func LiquidityTimeline() *cli.Command {
+	return &cli.Command{
+		Name:      "liquidity",
+		Usage:     "Shows who added and removed liquidity on a token's Uniswap V2 pair, and when",
+		ArgsUsage: "<token-address>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format (text or json)",
+				Value: "text",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file for json, stdout if not set",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if !common.IsHexAddress(ctx.Args().First()) {
+				return cli.Exit("Expected a token address", 1)
+			}
+			tokenAddress := common.HexToAddress(ctx.Args().First())
+
+			conf, err := config.LoadConfig()
+			if err != nil {
+				panic("Failed to load config:\n\n\t" + err.Error())
+			}
+			timeline, err := core.GenerateLiquidityTimeline(conf.EthNodeURL, tokenAddress)
+			if err != nil {
+				panic("Failed to generate liquidity timeline:\n\n\t" + err.Error())
+			}
+
+			if ctx.String("format") == "json" {
+				err = utils.WriteJSON(ctx.String("output"), timeline)
+				if err != nil {
+					panic("Failed to export liquidity timeline:\n\n\t" + err.Error())
+				}
+				return nil
+			}
+			core.PrintLiquidityTimeline(timeline)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+func Monitor() *cli.Command {
+	return &cli.Command{
+		Name:      "monitor",
+		Usage:     "Re-evaluates tracked tokens and classifies them as active, rugged or abandoned",
+		ArgsUsage: "[token-address...]",
+		Flags: []cli.Flag{
+			&cli.Float64Flag{
+				Name:  "liquidity-removal",
+				Usage: "Percentage of the WETH reserve a single removal must take to count as a rug",
+				Value: 50,
+			},
+			&cli.Float64Flag{
+				Name:  "price-drop",
+				Usage: "Percentage the price must fall within --price-window to count as a rug",
+				Value: 70,
+			},
+			&cli.DurationFlag{
+				Name:  "price-window",
+				Usage: "Window the price drop must happen within",
+				Value: 10 * time.Minute,
+			},
+			&cli.Float64Flag{
+				Name:  "mint-share",
+				Usage: "Percentage of the supply a single mint must add to count as a rug",
+				Value: 5,
+			},
+			&cli.DurationFlag{
+				Name:  "abandon-after",
+				Usage: "How long without trades before a token counts as abandoned",
+				Value: 72 * time.Hour,
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "Re-evaluate on this interval instead of once",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format (text or json)",
+				Value: "text",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file for json, stdout if not set",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			var tokenAddresses []common.Address
+			for _, arg := range ctx.Args().Slice() {
+				if !common.IsHexAddress(arg) {
+					return cli.Exit("Expected token addresses", 1)
+				}
+				tokenAddresses = append(tokenAddresses, common.HexToAddress(arg))
+			}
+
+			conf, err := config.LoadConfig()
+			if err != nil {
+				panic("Failed to load config:\n\n\t" + err.Error())
+			}
+			opts := core.MonitorOptions{
+				LiquidityRemovalPct: ctx.Float64("liquidity-removal"),
+				PriceDropPct:        ctx.Float64("price-drop"),
+				PriceWindow:         ctx.Duration("price-window"),
+				MintPct:             ctx.Float64("mint-share"),
+				AbandonAfter:        ctx.Duration("abandon-after"),
+			}
+
+			for {
+				tracked, err := core.GenerateMonitorReport(conf, tokenAddresses, opts)
+				if err != nil {
+					panic("Failed to monitor tokens:\n\n\t" + err.Error())
+				}
+
+				if ctx.String("format") == "json" {
+					err = utils.WriteJSON(ctx.String("output"), tracked)
+					if err != nil {
+						panic("Failed to export monitor report:\n\n\t" + err.Error())
+					}
+				} else {
+					core.PrintMonitorReport(tracked)
+				}
+
+				if ctx.Duration("interval") == 0 {
+					return nil
+				}
+				time.Sleep(ctx.Duration("interval"))
+			}
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core"
+)
+
+func ReserveHistory() *cli.Command {
+	return &cli.Command{
+		Name:      "reserves",
+		Usage:     "Reconstructs the reserve and price history of a token's Uniswap V2 pair from Sync events",
+		ArgsUsage: "<token-address>",
+		Flags: []cli.Flag{
+			&cli.Uint64Flag{
+				Name:  "from-block",
+				Usage: "Block to replay from, defaults to the pair's creation block",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format (csv or json)",
+				Value: "csv",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file, stdout if not set",
+			},
+			&cli.BoolFlag{
+				Name:  "plot",
+				Usage: "Plot the price and WETH reserve instead of exporting",
+			},
+			&cli.Float64Flag{
+				Name:  "drop-threshold",
+				Usage: "Percentage fall in WETH reserve reported as a liquidity drop",
+				Value: 50,
+			},
+			&cli.DurationFlag{
+				Name:  "drop-window",
+				Usage: "Window the WETH reserve has to fall within to count as a sudden drop",
+				Value: 10 * time.Minute,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if !common.IsHexAddress(ctx.Args().First()) {
+				return cli.Exit("Expected a token address", 1)
+			}
+			tokenAddress := common.HexToAddress(ctx.Args().First())
+
+			conf, err := config.LoadConfig()
+			if err != nil {
+				panic("Failed to load config:\n\n\t" + err.Error())
+			}
+			history, err := core.GenerateReserveHistory(conf.EthNodeURL, tokenAddress, ctx.Uint64("from-block"))
+			if err != nil {
+				panic("Failed to generate reserve history:\n\n\t" + err.Error())
+			}
+
+			// Keep stdout parseable when the export is written there
+			report := io.Writer(os.Stdout)
+			if ctx.Bool("plot") {
+				fmt.Println(core.PlotReserveHistory(history, 100, 20))
+			} else {
+				err = core.ExportReserveHistory(history, ctx.String("format"), ctx.String("output"))
+				if err != nil {
+					panic("Failed to export reserve history:\n\n\t" + err.Error())
+				}
+				if ctx.String("output") == "" {
+					report = os.Stderr
+				}
+			}
+
+			drops := core.DetectLiquidityDrops(history, ctx.Float64("drop-threshold"), ctx.Duration("drop-window"))
+			for _, drop := range drops {
+				fmt.Fprintf(report, "Liquidity drop of %.2f%% at block %d (%s): %s -> %s WETH\n",
+					drop.DropPct, drop.BlockNumber, drop.TxHash, drop.FromWETHReserve.Text('f', 4), drop.ToWETHReserve.Text('f', 4))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+func Snipers() *cli.Command {
+	return &cli.Command{
+		Name:      "snipers",
+		Usage:     "Flags suspected snipers and bots among a token's first buyers after launch",
+		ArgsUsage: "<token-address>",
+		Flags: []cli.Flag{
+			&cli.Uint64Flag{
+				Name:  "blocks",
+				Usage: "Number of blocks after the launch block to analyze",
+				Value: 5,
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format (text or json)",
+				Value: "text",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file for json, stdout if not set",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if !common.IsHexAddress(ctx.Args().First()) {
+				return cli.Exit("Expected a token address", 1)
+			}
+			tokenAddress := common.HexToAddress(ctx.Args().First())
+
+			conf, err := config.LoadConfig()
+			if err != nil {
+				panic("Failed to load config:\n\n\t" + err.Error())
+			}
+			addressLabels, err := labels.Load(conf.LabelsFile)
+			if err != nil {
+				panic("Failed to load address labels:\n\n\t" + err.Error())
+			}
+			report, err := core.GenerateSniperReport(conf.EthNodeURL, tokenAddress, ctx.Uint64("blocks"), addressLabels)
+			if err != nil {
+				panic("Failed to generate sniper report:\n\n\t" + err.Error())
+			}
+
+			if ctx.String("format") == "json" {
+				err = utils.WriteJSON(ctx.String("output"), report)
+				if err != nil {
+					panic("Failed to export sniper report:\n\n\t" + err.Error())
+				}
+				return nil
+			}
+			core.PrintSniperReport(report)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core"
+)
+
+func WatchTrades() *cli.Command {
+	return &cli.Command{
+		Name:      "trades",
+		Usage:     "Streams each swap on the token's Uniswap V2 pair as new blocks arrive",
+		ArgsUsage: "<token-address>",
+		Flags: []cli.Flag{
+			&cli.Uint64Flag{
+				Name:  "backfill-blocks",
+				Usage: "Number of past blocks to print trades for before following new blocks",
+				Value: 0,
+			},
+			&cli.DurationFlag{
+				Name:  "poll-interval",
+				Usage: "How often to check for new blocks",
+				Value: 3 * time.Second,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if !common.IsHexAddress(ctx.Args().First()) {
+				return cli.Exit("Expected a token address", 1)
+			}
+			tokenAddress := common.HexToAddress(ctx.Args().First())
+			if ctx.Duration("poll-interval") <= 0 {
+				return cli.Exit("Expected a positive --poll-interval", 1)
+			}
+
+			conf, err := config.LoadConfig()
+			if err != nil {
+				panic("Failed to load config:\n\n\t" + err.Error())
+			}
+			err = core.StreamTrades(conf.EthNodeURL, tokenAddress, ctx.Uint64("backfill-blocks"), ctx.Duration("poll-interval"))
+			if err != nil {
+				panic("Failed to stream trades:\n\n\t" + err.Error())
+			}
+			return nil
+		},
+	}
+}
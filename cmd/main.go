@@ -16,6 +16,14 @@ func main() {
 		Compiled: time.Now(),
 		Commands: []*cli.Command{
 			commands.GenerateProfiles(),
+			commands.BuildCandles(),
+			commands.WatchTrades(),
+			commands.ReserveHistory(),
+			commands.LiquidityTimeline(),
+			commands.Deployer(),
+			commands.Snipers(),
+			commands.Timeline(),
+			commands.Monitor(),
 		},
 	}
 
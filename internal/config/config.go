@@ -8,8 +8,37 @@ import (
 )
 
 type Config struct {
-	EthNodeURL      string `yaml:"eth_node_url"`
-	EtherscanAPIKey string `yaml:"etherscan_api_key"`
+	EthNodeURL         string         `yaml:"eth_node_url"`
+	EtherscanAPIKey    string         `yaml:"etherscan_api_key"`
+	EtherscanBaseURL   string         `yaml:"etherscan_base_url"`
+	EtherscanChainID   uint64         `yaml:"etherscan_chain_id"`
+	EtherscanRateLimit float64        `yaml:"etherscan_rate_limit"`
+	LPLockers          []LockerConfig `yaml:"lp_lockers"`
+	SimulationCacheDir string         `yaml:"simulation_cache_dir"`
+	DataDir            string         `yaml:"data_dir"`
+	LabelsFile         string         `yaml:"labels_file"`
+	SelectorsFile      string         `yaml:"selectors_file"`
+
+	// SupplyExclusions are addresses such as vesting contracts whose balances are left
+	// out of the circulating supply, on top of burn addresses, LP lockers and the token itself
+	SupplyExclusions      []AddressConfig `yaml:"supply_exclusions"`
+	ExcludeDeployerSupply bool            `yaml:"exclude_deployer_supply"`
+
+	// RiskWeights overrides the maximum points each risk factor can contribute, by factor name
+	RiskWeights map[string]float64 `yaml:"risk_weights"`
+}
+
+type AddressConfig struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+}
+
+// LockerConfig registers a known LP locker contract. Kind selects how lock expiries
+// are read on-chain ("uncx_v2" or "team_finance"), leave it empty to only label the locker.
+type LockerConfig struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+	Kind    string `yaml:"kind"`
 }
 
 const filePath = "C:\\Users\\zmcmanus\\go\\src\\github.com\\zachmdsi\\go-token-cli\\config.yaml"
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	Candles are built from the Swap events of the token's Uniswap V2 WETH pair.
+	Prices are in WETH per token, taken from the execution price of each swap.
+	Intervals without trades are filled with a flat candle at the previous close
+	so the output can be charted without gaps.
+*/
+
+func GenerateCandles(ethNodeURL string, tokenAddress common.Address, numBlocks uint64, interval time.Duration) ([]*types.Candle, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("\nCandle interval must be positive, got %s", interval)
+	}
+	cl, err := ethclient.Dial(ethNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
+	}
+
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+	startBlockNum := uint64(0)
+	if blockNum > numBlocks {
+		startBlockNum = blockNum - numBlocks
+	}
+
+	pair, err := dexes.GetUniswapPair(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetUniswapPair() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	} else if pair == nil {
+		return nil, fmt.Errorf("\nNo Uniswap V2 WETH pair found:\n\tToken Address: %s", tokenAddress)
+	}
+
+	swaps, err := dexes.GetUniswapSwaps(cl, pair, startBlockNum, blockNum)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetUniswapSwaps() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+
+	return BuildCandles(swaps, interval), nil
+}
+
+// BuildCandles buckets swaps into candles of the given interval, in time order whatever
+// order the swaps are given in. It returns nil for an interval of zero or less.
+func BuildCandles(swaps []*types.Swap, interval time.Duration) []*types.Candle {
+	if interval <= 0 {
+		return nil
+	}
+	sorted := append([]*types.Swap(nil), swaps...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	var candles []*types.Candle
+	var current *types.Candle
+	for _, swap := range sorted {
+		if swap.PriceInWETH == nil {
+			continue
+		}
+		start := swap.Timestamp.Truncate(interval)
+
+		if current == nil || start.After(current.Start) {
+			if current != nil {
+				for gap := current.Start.Add(interval); gap.Before(start); gap = gap.Add(interval) {
+					candles = append(candles, newCandle(gap, current.Close))
+				}
+			}
+			current = newCandle(start, swap.PriceInWETH)
+			candles = append(candles, current)
+		}
+
+		if swap.PriceInWETH.Cmp(current.High) > 0 {
+			current.High = swap.PriceInWETH
+		}
+		if swap.PriceInWETH.Cmp(current.Low) < 0 {
+			current.Low = swap.PriceInWETH
+		}
+		current.Close = swap.PriceInWETH
+		current.Volume = new(big.Float).Add(current.Volume, swap.TokenAmount)
+		current.VolumeWETH = new(big.Float).Add(current.VolumeWETH, swap.WETHAmount)
+		current.Trades++
+	}
+	return candles
+}
+
+func newCandle(start time.Time, price *big.Float) *types.Candle {
+	return &types.Candle{
+		Start:      start,
+		Open:       price,
+		High:       price,
+		Low:        price,
+		Close:      price,
+		Volume:     new(big.Float),
+		VolumeWETH: new(big.Float),
+	}
+}
+
+func ExportCandles(candles []*types.Candle, format string, path string) error {
+	switch format {
+	case "json":
+		return utils.WriteJSON(path, candles)
+	case "csv":
+		header := []string{"start", "open", "high", "low", "close", "volume", "volume_weth", "trades"}
+		var rows [][]string
+		for _, candle := range candles {
+			rows = append(rows, []string{
+				candle.Start.Format(time.RFC3339),
+				candle.Open.Text('g', 18),
+				candle.High.Text('g', 18),
+				candle.Low.Text('g', 18),
+				candle.Close.Text('g', 18),
+				candle.Volume.Text('f', 6),
+				candle.VolumeWETH.Text('f', 18),
+				strconv.FormatUint(candle.Trades, 10),
+			})
+		}
+		return utils.WriteCSV(path, header, rows)
+	default:
+		return fmt.Errorf("\nUnsupported export format: %s", format)
+	}
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+func testSwap(at time.Time, price float64) *types.Swap {
+	return &types.Swap{
+		Timestamp:   at,
+		PriceInWETH: big.NewFloat(price),
+		TokenAmount: big.NewFloat(10),
+		WETHAmount:  big.NewFloat(price * 10),
+	}
+}
+
+func TestBuildCandles(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	minute := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+
+	type candle struct {
+		start                  time.Time
+		open, high, low, close float64
+		trades                 uint64
+	}
+	tests := []struct {
+		name     string
+		swaps    []*types.Swap
+		interval time.Duration
+		want     []candle
+	}{
+		{
+			name:     "no swaps",
+			interval: 5 * time.Minute,
+			want:     nil,
+		},
+		{
+			name:     "single swap",
+			swaps:    []*types.Swap{testSwap(minute(7), 2)},
+			interval: 5 * time.Minute,
+			want:     []candle{{minute(5), 2, 2, 2, 2, 1}},
+		},
+		{
+			name:     "swaps in one interval",
+			swaps:    []*types.Swap{testSwap(minute(0), 2), testSwap(minute(1), 5), testSwap(minute(2), 1), testSwap(minute(4), 3)},
+			interval: 5 * time.Minute,
+			want:     []candle{{minute(0), 2, 5, 1, 3, 4}},
+		},
+		{
+			name:     "gaps filled at the previous close",
+			swaps:    []*types.Swap{testSwap(minute(1), 2), testSwap(minute(2), 3), testSwap(minute(16), 4)},
+			interval: 5 * time.Minute,
+			want: []candle{
+				{minute(0), 2, 3, 2, 3, 2},
+				{minute(5), 3, 3, 3, 3, 0},
+				{minute(10), 3, 3, 3, 3, 0},
+				{minute(15), 4, 4, 4, 4, 1},
+			},
+		},
+		{
+			name:     "unsorted input",
+			swaps:    []*types.Swap{testSwap(minute(6), 4), testSwap(minute(1), 2), testSwap(minute(3), 3)},
+			interval: 5 * time.Minute,
+			want: []candle{
+				{minute(0), 2, 3, 2, 3, 2},
+				{minute(5), 4, 4, 4, 4, 1},
+			},
+		},
+		{
+			name:     "swaps without a price are skipped",
+			swaps:    []*types.Swap{{Timestamp: minute(0)}, testSwap(minute(1), 2)},
+			interval: 5 * time.Minute,
+			want:     []candle{{minute(0), 2, 2, 2, 2, 1}},
+		},
+		{
+			name:     "zero interval",
+			swaps:    []*types.Swap{testSwap(minute(1), 2), testSwap(minute(16), 4)},
+			interval: 0,
+			want:     nil,
+		},
+		{
+			name:     "negative interval",
+			swaps:    []*types.Swap{testSwap(minute(1), 2)},
+			interval: -time.Minute,
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildCandles(tt.swaps, tt.interval)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d candles, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				c := got[i]
+				open, _ := c.Open.Float64()
+				high, _ := c.High.Float64()
+				low, _ := c.Low.Float64()
+				close, _ := c.Close.Float64()
+				if !c.Start.Equal(want.start) || open != want.open || high != want.high || low != want.low || close != want.close || c.Trades != want.trades {
+					t.Errorf("candle %d: got %s %g/%g/%g/%g %d trades, want %+v", i, c.Start, open, high, low, close, c.Trades, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/store"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+/*
+	Every profiled token's code fingerprint is kept in the store, one record per
+	normalized code hash listing the tokens that deployed it. A new token's family is
+	every record at least cloneSimilarity alike, so lightly edited copies of a template
+	count towards the same family as exact ones.
+
+	Whether earlier deployments rugged is only known later, so prior deployments are
+	checked again when they are matched and the last check is older than rugRecheckAge.
+	A rug is final and never checked again.
+*/
+
+const (
+	fingerprintNamespace = "fingerprints"
+	cloneSimilarity      = 0.9
+	rugRecheckAge        = 6 * time.Hour
+	// maxRugRechecks bounds the on-chain work done for one match
+	maxRugRechecks = 20
+)
+
+type CloneDB struct {
+	st      *store.Store
+	records map[common.Hash]*types.FingerprintRecord
+}
+
+// OpenCloneDB loads every stored fingerprint record
+func OpenCloneDB(st *store.Store) (*CloneDB, error) {
+	db := &CloneDB{st: st, records: make(map[common.Hash]*types.FingerprintRecord)}
+	keys, err := st.Keys(fingerprintNamespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		record := &types.FingerprintRecord{}
+		if _, err := st.Load(fingerprintNamespace, key, record); err != nil {
+			return nil, err
+		}
+		db.records[record.Hash] = record
+	}
+	return db, nil
+}
+
+// Match finds the clone family of a fingerprint, leaving the token itself out so a
+// token profiled twice isn't its own clone. It returns nil when nothing similar was seen.
+func (db *CloneDB) Match(cl *ethclient.Client, fingerprint *types.Fingerprint, tokenAddress common.Address) (*types.CloneFamily, error) {
+	if fingerprint == nil {
+		return nil, nil
+	}
+
+	var family *types.CloneFamily
+	deployers := make(map[common.Address]bool)
+	rechecks := 0
+	for _, record := range db.records {
+		similarity := contracts.Similarity(fingerprint, &record.Fingerprint)
+		if similarity < cloneSimilarity {
+			continue
+		}
+
+		changed := false
+		for i := range record.Deployments {
+			deployment := &record.Deployments[i]
+			if deployment.Token == tokenAddress {
+				continue
+			}
+			if !deployment.Rugged && time.Since(deployment.CheckedAt) > rugRecheckAge && rechecks < maxRugRechecks {
+				rechecks++
+				rugged, reason, err := checkRugged(cl, deployment.Token)
+				if err != nil {
+					return nil, fmt.Errorf("\ncheckRugged() failed:\n\tToken Address: %s\n\tError: %v", deployment.Token, err)
+				}
+				deployment.Rugged, deployment.RugReason = rugged, reason
+				deployment.CheckedAt = time.Now().UTC()
+				changed = true
+			}
+
+			if family == nil {
+				family = &types.CloneFamily{Original: *deployment}
+			}
+			if deployment.BlockNumber < family.Original.BlockNumber {
+				family.Original = *deployment
+			}
+			if record.Hash == fingerprint.Hash {
+				family.Exact = true
+			}
+			if similarity > family.Similarity {
+				family.Similarity = similarity
+			}
+			family.PriorDeployments++
+			if deployment.Rugged {
+				family.RuggedCount++
+			}
+			deployers[deployment.Deployer] = true
+		}
+		if changed {
+			if err := db.save(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if family != nil {
+		family.Deployers = len(deployers)
+	}
+	return family, nil
+}
+
+// Record adds a deployment of the fingerprinted code, replacing an earlier entry for
+// the same token
+func (db *CloneDB) Record(fingerprint *types.Fingerprint, deployment types.CloneDeployment) error {
+	if fingerprint == nil {
+		return nil
+	}
+	record, ok := db.records[fingerprint.Hash]
+	if !ok {
+		record = &types.FingerprintRecord{Fingerprint: *fingerprint}
+		db.records[fingerprint.Hash] = record
+	}
+	for i, existing := range record.Deployments {
+		if existing.Token == deployment.Token {
+			// Keep what is known about a rug from earlier checks
+			deployment.Rugged, deployment.RugReason, deployment.CheckedAt = existing.Rugged, existing.RugReason, existing.CheckedAt
+			record.Deployments[i] = deployment
+			return db.save(record)
+		}
+	}
+	record.Deployments = append(record.Deployments, deployment)
+	sort.SliceStable(record.Deployments, func(i, j int) bool {
+		return record.Deployments[i].BlockNumber < record.Deployments[j].BlockNumber
+	})
+	return db.save(record)
+}
+
+func (db *CloneDB) save(record *types.FingerprintRecord) error {
+	return db.st.Save(fingerprintNamespace, record.Hash.Hex(), record)
+}
+
+func describeCloneFamily(family *types.CloneFamily) string {
+	name := family.Original.Token.Hex()
+	if family.Original.Symbol != "" {
+		name = fmt.Sprintf("%s (%s)", family.Original.Symbol, family.Original.Token)
+	}
+	kind := "clone"
+	if !family.Exact {
+		kind = fmt.Sprintf("%.0f%% similar clone", family.Similarity*100)
+	}
+	return fmt.Sprintf("%s of %s (%d prior deployments, %d rugged)", kind, name, family.PriorDeployments, family.RuggedCount)
+}
@@ -0,0 +1,245 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/store"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	The largest holders are clustered with a union-find over three kinds of links:
+
+		- shared funder:    first funded by the same address, or by another holder. Labelled
+		                    funders (exchanges, bridges, mixers) and contracts fund unrelated
+		                    users too, so they don't link holders.
+		- same funding tx:  first funded in the same transaction, e.g. by a disperse contract
+		- token transfers:  tokens sent from one holder to another
+
+	Candidates are the largest holders that are neither contracts nor labelled. Pools,
+	lockers and exchange wallets trade with everyone, so letting them into a cluster,
+	directly or as the other side of a transfer, would merge unrelated holders.
+
+	First fundings never change, so they're kept in the store to avoid repeating the
+	binary searches on later runs.
+*/
+
+const (
+	clusterCandidateCount = 50
+	fundingNamespace      = "funding"
+)
+
+type unionFind struct {
+	parent  map[common.Address]common.Address
+	reasons map[common.Address][]string
+}
+
+func newUnionFind() *unionFind {
+	return &unionFind{
+		parent:  make(map[common.Address]common.Address),
+		reasons: make(map[common.Address][]string),
+	}
+}
+
+func (u *unionFind) find(address common.Address) common.Address {
+	parent, ok := u.parent[address]
+	if !ok || parent == address {
+		return address
+	}
+	root := u.find(parent)
+	u.parent[address] = root
+	return root
+}
+
+func (u *unionFind) union(a, b common.Address, reason string) {
+	rootA, rootB := u.find(a), u.find(b)
+	if rootA != rootB {
+		u.parent[rootB] = rootA
+		u.reasons[rootA] = append(u.reasons[rootA], u.reasons[rootB]...)
+		delete(u.reasons, rootB)
+	}
+	for _, existing := range u.reasons[rootA] {
+		if existing == reason {
+			return
+		}
+	}
+	u.reasons[rootA] = append(u.reasons[rootA], reason)
+}
+
+// ClusterHolders groups the largest holders into clusters of two or more, sorted by
+// their combined share of the total supply
+func ClusterHolders(cl *ethclient.Client, st *store.Store, index *types.HolderIndex, creationBlock uint64, pair *types.UniswapPair, addressLabels labels.Labels) ([]*types.WalletCluster, error) {
+	excluded := map[common.Address]bool{
+		{}:                true,
+		utils.DeadAddress: true,
+		index.Token:       true,
+	}
+	if pair != nil {
+		excluded[pair.Address] = true
+	}
+
+	var holders []common.Address
+	for address, balance := range index.Balances {
+		if balance.Sign() > 0 && !excluded[address] {
+			holders = append(holders, address)
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if cmp := index.Balances[holders[i]].Cmp(index.Balances[holders[j]]); cmp != 0 {
+			return cmp > 0
+		}
+		return holders[i].Hex() < holders[j].Hex()
+	})
+
+	var candidates []common.Address
+	for _, address := range holders {
+		if len(candidates) == clusterCandidateCount {
+			break
+		}
+		if _, labelled := addressLabels.Lookup(address); labelled {
+			continue
+		}
+		code, err := cl.CodeAt(context.Background(), address, nil)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get code at %s: %v", address, err)
+		} else if len(code) > 0 {
+			continue
+		}
+		candidates = append(candidates, address)
+	}
+	isCandidate := make(map[common.Address]bool)
+	for _, address := range candidates {
+		isCandidate[address] = true
+	}
+
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+
+	u := newUnionFind()
+	byFunder := make(map[common.Address]common.Address)
+	byFundingTx := make(map[common.Hash]common.Address)
+	for _, address := range candidates {
+		funding, err := getCachedFirstFunding(cl, st, address, blockNum)
+		if err != nil {
+			return nil, err
+		} else if funding == nil {
+			continue
+		}
+
+		if funding.TxHash != (common.Hash{}) {
+			if other, ok := byFundingTx[funding.TxHash]; ok {
+				u.union(other, address, "funded in the same transaction")
+			} else {
+				byFundingTx[funding.TxHash] = address
+			}
+		}
+
+		funder := funding.Funder
+		if funder == (common.Address{}) || funding.Internal {
+			continue
+		}
+		if isCandidate[funder] {
+			u.union(funder, address, "funded by another holder")
+			continue
+		}
+		if _, labelled := addressLabels.Lookup(funder); labelled {
+			continue
+		}
+		code, err := cl.CodeAt(context.Background(), funder, nil)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get code at %s: %v", funder, err)
+		} else if len(code) > 0 {
+			continue
+		}
+		if other, ok := byFunder[funder]; ok {
+			u.union(other, address, "shared funder "+funder.Hex())
+		} else {
+			byFunder[funder] = address
+		}
+	}
+
+	// Only transfers between two candidates are needed, which the topic filters select directly
+	if len(candidates) > 1 {
+		var topics []common.Hash
+		for _, address := range candidates {
+			topics = append(topics, common.BytesToHash(address.Bytes()))
+		}
+		logs, err := utils.FilterLogs(cl, []common.Address{index.Token}, [][]common.Hash{{transferEventID}, topics, topics}, creationBlock, blockNum)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get Transfer logs between holders:\n\tToken Address: %s\n\tError: %v", index.Token, err)
+		}
+		for _, log := range logs {
+			if len(log.Topics) != 3 {
+				continue
+			}
+			from := common.BytesToAddress(log.Topics[1].Bytes())
+			to := common.BytesToAddress(log.Topics[2].Bytes())
+			if from != to {
+				u.union(from, to, "token transfers between members")
+			}
+		}
+	}
+
+	totalSupply, err := getRawTotalSupply(cl, index.Token)
+	if err != nil {
+		return nil, err
+	}
+	clustersByRoot := make(map[common.Address]*types.WalletCluster)
+	var clusters []*types.WalletCluster
+	for _, address := range candidates {
+		root := u.find(address)
+		cluster, ok := clustersByRoot[root]
+		if !ok {
+			cluster = &types.WalletCluster{
+				Balance: new(big.Int),
+				Reasons: u.reasons[root],
+			}
+			clustersByRoot[root] = cluster
+			clusters = append(clusters, cluster)
+		}
+		cluster.Members = append(cluster.Members, address)
+		cluster.Balance.Add(cluster.Balance, index.Balances[address])
+	}
+
+	var linked []*types.WalletCluster
+	for _, cluster := range clusters {
+		if len(cluster.Members) > 1 {
+			cluster.Share = sharePct(cluster.Balance, totalSupply)
+			linked = append(linked, cluster)
+		}
+	}
+	sort.SliceStable(linked, func(i, j int) bool {
+		return linked[i].Balance.Cmp(linked[j].Balance) > 0
+	})
+	return linked, nil
+}
+
+func getCachedFirstFunding(cl *ethclient.Client, st *store.Store, address common.Address, beforeBlock uint64) (*types.FundingHop, error) {
+	var funding types.FundingHop
+	found, err := st.Load(fundingNamespace, address.Hex(), &funding)
+	if err != nil {
+		return nil, err
+	} else if found {
+		return &funding, nil
+	}
+
+	hop, err := findFirstFunding(cl, address, beforeBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nfindFirstFunding() failed:\n\tAddress: %s\n\tError: %v", address, err)
+	} else if hop == nil {
+		return nil, nil
+	}
+	if err := st.Save(fundingNamespace, address.Hex(), hop); err != nil {
+		return nil, err
+	}
+	return hop, nil
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	Concentration is measured over the held supply, after leaving out:
+
+		- the zero and dead addresses
+		- the token's Uniswap pair and any other V2 style pool holding the token, found
+		  by calling token0()/token1() on contract holders
+
+	Looking up code for every holder is too slow for tokens with thousands of holders, so
+	only the largest classifiedHolderCount holders are checked for code. Smaller holders
+	are counted as EOAs, which barely moves the contract share since it is dominated by
+	the largest balances anyway.
+
+	The Nakamoto coefficient is the smallest number of holders that together hold more
+	than half of the held supply.
+*/
+
+const classifiedHolderCount = 200
+
+func AnalyzeConcentration(cl *ethclient.Client, index *types.HolderIndex, pair *types.UniswapPair) (*types.HolderConcentration, error) {
+	pairABI, err := abi.JSON(strings.NewReader(utils.UniswapV2PairABI))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to parse UniswapV2PairABI: %v", err)
+	}
+
+	excluded := map[common.Address]bool{
+		{}:                true,
+		utils.DeadAddress: true,
+	}
+	if pair != nil {
+		excluded[pair.Address] = true
+	}
+
+	type holder struct {
+		address common.Address
+		balance *big.Int
+	}
+	var holders []holder
+	for address, balance := range index.Balances {
+		if balance.Sign() > 0 && !excluded[address] {
+			holders = append(holders, holder{address, balance})
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if cmp := holders[i].balance.Cmp(holders[j].balance); cmp != 0 {
+			return cmp > 0
+		}
+		return holders[i].address.Hex() < holders[j].address.Hex()
+	})
+
+	concentration := &types.HolderConcentration{}
+	for address := range excluded {
+		if index.Balances[address] != nil && index.Balances[address].Sign() > 0 {
+			concentration.Excluded = append(concentration.Excluded, address)
+		}
+	}
+	// Map order changes between runs, pools found below keep the holder order
+	sort.Slice(concentration.Excluded, func(i, j int) bool {
+		return concentration.Excluded[i].Hex() < concentration.Excluded[j].Hex()
+	})
+
+	// Classify the largest holders, dropping any pools found among the contracts
+	contractHeld := new(big.Int)
+	var kept []holder
+	for i, h := range holders {
+		if i >= classifiedHolderCount {
+			kept = append(kept, holders[i:]...)
+			break
+		}
+		code, err := cl.CodeAt(context.Background(), h.address, nil)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get code at %s: %v", h.address, err)
+		}
+		if len(code) > 0 {
+			if isPool(cl, pairABI, h.address, index.Token) {
+				concentration.Excluded = append(concentration.Excluded, h.address)
+				continue
+			}
+			contractHeld.Add(contractHeld, h.balance)
+		}
+		kept = append(kept, h)
+	}
+	holders = kept
+
+	held := new(big.Int)
+	for _, h := range holders {
+		held.Add(held, h.balance)
+	}
+	concentration.Holders = uint64(len(holders))
+	if held.Sign() == 0 {
+		return concentration, nil
+	}
+
+	topShare := func(n int) float64 {
+		sum := new(big.Int)
+		for i := 0; i < n && i < len(holders); i++ {
+			sum.Add(sum, holders[i].balance)
+		}
+		return sharePct(sum, held)
+	}
+	concentration.Top10Pct = topShare(10)
+	concentration.Top50Pct = topShare(50)
+	concentration.ContractPct = sharePct(contractHeld, held)
+	concentration.EOAPct = 100 - concentration.ContractPct
+
+	cumulative := new(big.Int)
+	half := new(big.Int).Rsh(held, 1)
+	for i, h := range holders {
+		cumulative.Add(cumulative, h.balance)
+		if cumulative.Cmp(half) > 0 {
+			concentration.Nakamoto = uint64(i + 1)
+			break
+		}
+	}
+
+	// Gini over balances in ascending order: (2 * sum(i * b_i)) / (n * sum(b_i)) - (n + 1) / n
+	n := float64(len(holders))
+	total, _ := new(big.Float).SetInt(held).Float64()
+	var weighted float64
+	for i := range holders {
+		balance, _ := new(big.Float).SetInt(holders[len(holders)-1-i].balance).Float64()
+		weighted += float64(i+1) * balance
+	}
+	concentration.Gini = 2*weighted/(n*total) - (n+1)/n
+
+	return concentration, nil
+}
+
+// isPool reports whether a contract is a V2 style pool with the token on one side
+func isPool(cl *ethclient.Client, pairABI abi.ABI, address, tokenAddress common.Address) bool {
+	pool := bind.NewBoundContract(address, pairABI, cl, cl, cl)
+	for _, method := range []string{"token0", "token1"} {
+		var result []interface{}
+		if err := pool.Call(&bind.CallOpts{}, &result, method); err != nil {
+			return false
+		}
+		if result[0].(common.Address) == tokenAddress {
+			return true
+		}
+	}
+	return false
+}
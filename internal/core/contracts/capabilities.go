@@ -0,0 +1,157 @@
+package contracts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+/*
+	Privileged functions are found by collecting every PUSH4 immediate in the runtime
+	code, which is how the Solidity and Vyper dispatchers compare selectors, and looking
+	them up in a selector database. The database is one entry per line:
+
+		<selector> <signature> <capability>
+
+	A default database is built in, and the selectors_file from the config is loaded on
+	top of it. The same pass looks for the SELFDESTRUCT and DELEGATECALL opcodes, skipping
+	PUSH data and the trailing Solidity metadata so constants aren't mistaken for opcodes.
+*/
+
+const (
+	CapabilitySelfDestruct = "selfdestruct"
+	CapabilityDelegateCall = "delegatecall"
+)
+
+//go:embed selectors.txt
+var defaultSelectors []byte
+
+type SelectorEntry struct {
+	Signature  string
+	Capability string
+}
+
+type SelectorDB map[[4]byte]SelectorEntry
+
+// LoadSelectorDB returns the built-in selectors extended with those in path, if set
+func LoadSelectorDB(path string) (SelectorDB, error) {
+	db := make(SelectorDB)
+	if err := db.read(bytes.NewReader(defaultSelectors), "built-in selectors"); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return db, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("\nFailed to open selectors file %s: %s", path, err.Error())
+	}
+	defer f.Close()
+	if err := db.read(f, path); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db SelectorDB) read(r io.Reader, name string) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return fmt.Errorf("\nInvalid selector on line %d of %s", line, name)
+		}
+		selector, err := hexutil.Decode(fields[0])
+		if err != nil || len(selector) != 4 {
+			return fmt.Errorf("\nInvalid selector on line %d of %s", line, name)
+		}
+		var key [4]byte
+		copy(key[:], selector)
+		db[key] = SelectorEntry{Signature: fields[1], Capability: fields[2]}
+	}
+	return scanner.Err()
+}
+
+func ScanCapabilities(cl *ethclient.Client, contractAddress common.Address, db SelectorDB) ([]types.Capability, error) {
+	code, err := cl.CodeAt(context.Background(), contractAddress, nil)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get code at %s: %s", contractAddress, err.Error())
+	}
+	return ScanBytecode(code, db), nil
+}
+
+// ScanBytecode lists the privileged functions and dangerous opcodes in runtime code
+func ScanBytecode(code []byte, db SelectorDB) []types.Capability {
+	code = stripMetadata(code)
+
+	var capabilities []types.Capability
+	seen := make(map[[4]byte]bool)
+	var selfDestruct, delegateCall bool
+	for pc := 0; pc < len(code); pc++ {
+		op := vm.OpCode(code[pc])
+		switch {
+		case op == vm.PUSH4 && pc+4 < len(code):
+			var selector [4]byte
+			copy(selector[:], code[pc+1:pc+5])
+			if entry, ok := db[selector]; ok && !seen[selector] {
+				seen[selector] = true
+				capabilities = append(capabilities, types.Capability{
+					Name:      entry.Capability,
+					Signature: entry.Signature,
+					Selector:  hexutil.Encode(selector[:]),
+				})
+			}
+		case op == vm.SELFDESTRUCT:
+			selfDestruct = true
+		case op == vm.DELEGATECALL:
+			delegateCall = true
+		}
+		if op.IsPush() {
+			pc += int(op - vm.PUSH1 + 1)
+		}
+	}
+
+	sort.SliceStable(capabilities, func(i, j int) bool {
+		return capabilities[i].Name < capabilities[j].Name
+	})
+	if selfDestruct {
+		capabilities = append(capabilities, types.Capability{Name: CapabilitySelfDestruct, Signature: "SELFDESTRUCT"})
+	}
+	if delegateCall {
+		capabilities = append(capabilities, types.Capability{Name: CapabilityDelegateCall, Signature: "DELEGATECALL"})
+	}
+	return capabilities
+}
+
+// stripMetadata removes the CBOR metadata Solidity appends to runtime code, whose
+// length is stored in the last two bytes
+func stripMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	// Metadata is a CBOR map, which starts with 0xa1-0xa5 for the handful of keys solc writes
+	start := len(code) - 2 - length
+	if length == 0 || start < 0 || code[start] < 0xa1 || code[start] > 0xa5 {
+		return code
+	}
+	return code[:start]
+}
@@ -0,0 +1,109 @@
+package contracts
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+/*
+	A deployment transaction's input is the init code followed by the ABI encoded
+	constructor arguments. The init code carries a copy of the runtime code, so the
+	arguments start right after the last copy of the runtime's metadata suffix, which
+	doesn't change between init and runtime code the way immutables do. Explorers also
+	publish the arguments of verified contracts, and those are used when the input ends
+	with them.
+
+	With an ABI the arguments are decoded by name and type. Without one each 32 byte word
+	is shown on its own, as an address when it looks like one and a number otherwise.
+*/
+
+// SplitConstructorArgs separates the constructor arguments from the init code of a
+// deployment transaction, reporting false when the boundary can't be found
+func SplitConstructorArgs(input, runtime []byte, published string) ([]byte, []byte, bool) {
+	if published != "" {
+		args, err := hexutil.Decode("0x" + strings.TrimPrefix(published, "0x"))
+		if err == nil && bytes.HasSuffix(input, args) {
+			return input[:len(input)-len(args)], args, true
+		}
+	}
+
+	stripped := stripMetadata(runtime)
+	if len(stripped) == len(runtime) {
+		// Without metadata only an unchanged copy of the runtime code marks the boundary
+		if end := bytes.LastIndex(input, runtime); end >= 0 && len(runtime) > 0 {
+			end += len(runtime)
+			return input[:end], input[end:], true
+		}
+		return input, nil, false
+	}
+	metadata := runtime[len(stripped):]
+	end := bytes.LastIndex(input, metadata)
+	if end < 0 {
+		return input, nil, false
+	}
+	end += len(metadata)
+	return input[:end], input[end:], true
+}
+
+// DecodeConstructorArgs decodes the arguments against the constructor in abiJSON, or
+// word by word when abiJSON is empty or has no constructor. The bool reports whether
+// the ABI was used.
+func DecodeConstructorArgs(args []byte, abiJSON string) ([]types.ConstructorArg, bool, error) {
+	if abiJSON != "" {
+		parsed, err := abi.JSON(strings.NewReader(abiJSON))
+		if err != nil {
+			return nil, false, fmt.Errorf("\nFailed to parse ABI: %v", err)
+		}
+		if len(parsed.Constructor.Inputs) > 0 {
+			values, err := parsed.Constructor.Inputs.Unpack(args)
+			if err != nil {
+				return nil, false, fmt.Errorf("\nFailed to unpack constructor arguments: %v", err)
+			}
+			var decoded []types.ConstructorArg
+			for i, input := range parsed.Constructor.Inputs {
+				decoded = append(decoded, types.ConstructorArg{
+					Name:  input.Name,
+					Type:  input.Type.String(),
+					Value: formatArgValue(values[i]),
+				})
+			}
+			return decoded, true, nil
+		}
+	}
+
+	var decoded []types.ConstructorArg
+	for offset := 0; offset+32 <= len(args); offset += 32 {
+		word := args[offset : offset+32]
+		arg := types.ConstructorArg{Name: fmt.Sprintf("word%d", offset/32)}
+		value := new(big.Int).SetBytes(word)
+		// Addresses fill the low 20 bytes, anything that small fits in a plain number
+		if bytes.Equal(word[:12], make([]byte, 12)) && value.BitLen() > 64 {
+			arg.Type = "address"
+			arg.Value = common.BytesToAddress(word).Hex()
+		} else {
+			arg.Type = "uint256"
+			arg.Value = value.String()
+		}
+		decoded = append(decoded, arg)
+	}
+	return decoded, false, nil
+}
+
+func formatArgValue(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return hexutil.Encode(v)
+	case [32]byte:
+		return hexutil.Encode(v[:])
+	case common.Address:
+		return v.Hex()
+	}
+	return fmt.Sprint(value)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -66,8 +68,10 @@ func NewBoundContract(cl *ethclient.Client, tokenAddress common.Address) (*bind.
 	return contract, nil
 }
 
-func FindCreatedContracts(ethNodeURL string, numBlocks uint64) ([]string, error) {
-	fmt.Println("\nSearching for created contracts")
+// FindCreatedContracts lists the contracts deployed directly by a transaction in the
+// last numBlocks blocks, with the deployer and deployment transaction of each
+func FindCreatedContracts(ethNodeURL string, numBlocks uint64) ([]*types.ContractCreation, error) {
+	fmt.Fprintln(os.Stderr, "\nSearching for created contracts")
 	cl, err := ethclient.Dial(ethNodeURL)
 	if err != nil {
 		return nil, fmt.Errorf("\nFailed to create ethclient: %s", err.Error())
@@ -79,8 +83,8 @@ func FindCreatedContracts(ethNodeURL string, numBlocks uint64) ([]string, error)
 	}
 	startBlockNum := blockNum - numBlocks
 
-	fmt.Printf("Iterate over %d blocks from %d -> %d\n", numBlocks, startBlockNum, blockNum)
-	var addresses []string
+	fmt.Fprintf(os.Stderr, "Iterate over %d blocks from %d -> %d\n", numBlocks, startBlockNum, blockNum)
+	var creations []*types.ContractCreation
 	for i := startBlockNum; i <= blockNum; i++ {
 		block, err := cl.BlockByNumber(context.Background(), big.NewInt(int64(i)))
 		if err != nil {
@@ -88,30 +92,101 @@ func FindCreatedContracts(ethNodeURL string, numBlocks uint64) ([]string, error)
 		}
 
 		for _, tx := range block.Transactions() {
-			if tx.To() == nil {
-				addresses = append(addresses, tx.Hash().Hex())
+			if tx.To() != nil {
+				continue
 			}
+			from, err := gethtypes.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+			if err != nil {
+				return nil, fmt.Errorf("\nFailed to get the signer: %s", err.Error())
+			}
+			creations = append(creations, &types.ContractCreation{
+				Contract:    crypto.CreateAddress(from, tx.Nonce()),
+				Creator:     from,
+				TxHash:      tx.Hash(),
+				BlockNumber: i,
+				Timestamp:   time.Unix(int64(block.Time()), 0).UTC(),
+			})
 		}
 	}
 
-	fmt.Printf("Found %d newly created contracts\n", len(addresses))
+	fmt.Fprintf(os.Stderr, "Found %d newly created contracts\n", len(creations))
 
-	return addresses, nil
+	return creations, nil
 }
 
-func GetContractAddress(cl *ethclient.Client, txHash common.Hash) (common.Address, error) {
+// GetContractAddress returns the address of the contract created by a deployment
+// transaction together with the deployer that sent it
+func GetContractAddress(cl *ethclient.Client, txHash common.Hash) (common.Address, common.Address, error) {
 	tx, _, err := cl.TransactionByHash(context.Background(), txHash)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("\nFailed to convert tx hash to a Transaction: %s", err.Error())
+		return common.Address{}, common.Address{}, fmt.Errorf("\nFailed to convert tx hash to a Transaction: %s", err.Error())
 	}
 
 	signer := gethtypes.LatestSignerForChainID(tx.ChainId())
 	from, err := signer.Sender(tx)
 	if err != nil {
-		return common.Address{}, fmt.Errorf("\nFailed to get the signer: %s", err.Error())
+		return common.Address{}, common.Address{}, fmt.Errorf("\nFailed to get the signer: %s", err.Error())
 	}
 
 	// Derive the contract address from the transaction sender and nonce
 	contractAddress := crypto.CreateAddress(from, tx.Nonce())
-	return contractAddress, nil
-}
\ No newline at end of file
+	return contractAddress, from, nil
+}
+
+// GetContractCreator finds the deployment of a contract by locating its creation block
+// and matching the block's transactions against the contract address. Contracts deployed
+// by a factory are attributed to the sender of the first transaction in that block whose
+// receipt has logs from the contract.
+func GetContractCreator(cl *ethclient.Client, contractAddress common.Address) (*types.ContractCreation, error) {
+	creationBlock, err := utils.FindCreationBlock(cl, contractAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nFindCreationBlock() failed:\n\tContract Address: %s\n\tError: %s", contractAddress, err.Error())
+	}
+	block, err := cl.BlockByNumber(context.Background(), new(big.Int).SetUint64(creationBlock))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block %d: %s", creationBlock, err.Error())
+	}
+
+	creation := &types.ContractCreation{
+		BlockNumber: creationBlock,
+		Timestamp:   time.Unix(int64(block.Time()), 0).UTC(),
+	}
+
+	for _, tx := range block.Transactions() {
+		if tx.To() != nil {
+			continue
+		}
+		from, err := gethtypes.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get the signer: %s", err.Error())
+		}
+		if crypto.CreateAddress(from, tx.Nonce()) == contractAddress {
+			creation.Contract = contractAddress
+			creation.Creator = from
+			creation.TxHash = tx.Hash()
+			return creation, nil
+		}
+	}
+
+	for _, tx := range block.Transactions() {
+		receipt, err := cl.TransactionReceipt(context.Background(), tx.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get receipt for %s: %s", tx.Hash(), err.Error())
+		}
+		for _, log := range receipt.Logs {
+			if log.Address != contractAddress {
+				continue
+			}
+			from, err := gethtypes.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+			if err != nil {
+				return nil, fmt.Errorf("\nFailed to get the signer: %s", err.Error())
+			}
+			creation.Contract = contractAddress
+			creation.Creator = from
+			creation.TxHash = tx.Hash()
+			return creation, nil
+		}
+	}
+
+	return nil, fmt.Errorf("\nNo deployment transaction found:\n\tContract Address: %s\n\tBlock: %d", contractAddress, creationBlock)
+}
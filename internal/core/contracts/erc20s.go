@@ -3,6 +3,7 @@ package contracts
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -24,40 +25,30 @@ func NewERC20(cl *ethclient.Client, tokenAddress common.Address) (*types.ERC20,
 	}, nil
 }
 
-func FindERC20Tokens(ethNodeURL string, txs []string) ([]string, error) {
-	fmt.Println("\nFinding new ERC20 tokens")
+// FindERC20Tokens keeps the created contracts that answer the ERC20 calls
+func FindERC20Tokens(ethNodeURL string, creations []*types.ContractCreation) ([]*types.ContractCreation, error) {
+	fmt.Fprintln(os.Stderr, "\nFinding new ERC20 tokens")
 
 	cl, err := ethclient.Dial(ethNodeURL)
 	if err != nil {
 		return nil, fmt.Errorf("\nFailed to create ethclient: %s", err.Error())
 	}
 
-	// Iterate through the list of contract creation txs to get the actual contract address
-	var toAddresses []string
-	for _, tx := range txs {
-		txHash := common.HexToHash(tx)
-		contractAddress, err := GetContractAddress(cl, txHash)
-		if err != nil {
-			return nil, fmt.Errorf("\nGetContractAddress() failed: %s", err.Error())
-		}
-		toAddresses = append(toAddresses, contractAddress.Hex())
-	}
-
 	// Iterate throught the contract addresses to check its' ABI to see if it as an ERC20 token
-	var erc20Addresses []string
-	for _, contractAddress := range toAddresses {
-		isERC20, err := IsERC20Contract(cl, common.HexToAddress(contractAddress))
+	var erc20Creations []*types.ContractCreation
+	for _, creation := range creations {
+		isERC20, err := IsERC20Contract(cl, creation.Contract)
 		if err != nil {
-			return nil, fmt.Errorf("\nIsERC20Address() failed:\n\tContract Address: %s\n\tError: %s", contractAddress, err.Error())
+			return nil, fmt.Errorf("\nIsERC20Address() failed:\n\tContract Address: %s\n\tError: %s", creation.Contract, err.Error())
 		}
 		if isERC20 {
-			erc20Addresses = append(erc20Addresses, contractAddress)
+			erc20Creations = append(erc20Creations, creation)
 		}
 	}
 
-	fmt.Printf("Found %d new ERC20 tokens\n", len(erc20Addresses))
+	fmt.Fprintf(os.Stderr, "Found %d new ERC20 tokens\n", len(erc20Creations))
 
-	return erc20Addresses, nil
+	return erc20Creations, nil
 }
 
 func IsERC20Contract(cl *ethclient.Client, contractAddress common.Address) (bool, error) {
@@ -0,0 +1,152 @@
+package contracts
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+/*
+	Copies of a template differ in their metadata hash, the addresses they were configured
+	with and the values baked into immutables. Normalizing runtime code drops the metadata
+	suffix and zeroes those values before hashing:
+
+		PUSH20 data		always, it is an address
+		PUSH32 data		when the first 4 bytes are zero, which covers addresses and
+					amounts in immutables but keeps event topics and masks
+
+	The hash of the normalized code matches exact clones. Templates that were lightly
+	edited are matched with a MinHash signature over runs of opcodes, which estimates the
+	share of opcode sequences two contracts have in common. Code shorter than one run of
+	opcodes has no signature and only matches by hash.
+*/
+
+const (
+	minHashSize   = 64
+	shingleLength = 8
+)
+
+// minHashSeeds are the per-slot multipliers and offsets of the MinHash functions
+var minHashSeeds = func() [minHashSize][2]uint64 {
+	var seeds [minHashSize][2]uint64
+	state := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		// splitmix64, so the signature is the same on every run
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for i := range seeds {
+		seeds[i] = [2]uint64{next() | 1, next()}
+	}
+	return seeds
+}()
+
+// FingerprintContract fingerprints the runtime code at an address, returning nil when
+// there is no code
+func FingerprintContract(cl *ethclient.Client, contractAddress common.Address) (*types.Fingerprint, error) {
+	code, err := cl.CodeAt(context.Background(), contractAddress, nil)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get code:\n\tContract Address: %s\n\tError: %v", contractAddress, err)
+	}
+	return FingerprintBytecode(code), nil
+}
+
+func FingerprintBytecode(code []byte) *types.Fingerprint {
+	if len(code) == 0 {
+		return nil
+	}
+	normalized := NormalizeBytecode(code)
+	return &types.Fingerprint{
+		Hash:    crypto.Keccak256Hash(normalized),
+		MinHash: minHash(normalized),
+	}
+}
+
+// NormalizeBytecode strips the metadata and zeroes embedded addresses and immutables
+func NormalizeBytecode(code []byte) []byte {
+	normalized := append([]byte(nil), stripMetadata(code)...)
+	for pc := 0; pc < len(normalized); pc++ {
+		op := vm.OpCode(normalized[pc])
+		if !op.IsPush() {
+			continue
+		}
+		size := int(op - vm.PUSH1 + 1)
+		end := pc + 1 + size
+		if end > len(normalized) {
+			end = len(normalized)
+		}
+		data := normalized[pc+1 : end]
+		if op == vm.PUSH20 || (op == vm.PUSH32 && len(data) == 32 && binary.BigEndian.Uint32(data) == 0) {
+			for i := range data {
+				data[i] = 0
+			}
+		}
+		pc += size
+	}
+	return normalized
+}
+
+// Similarity estimates the share of opcode sequences two fingerprints have in common
+func Similarity(a, b *types.Fingerprint) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+	if a.Hash == b.Hash {
+		return 1
+	}
+	if len(a.MinHash) != minHashSize || len(b.MinHash) != minHashSize {
+		return 0
+	}
+	var matches int
+	for i := range a.MinHash {
+		if a.MinHash[i] == b.MinHash[i] {
+			matches++
+		}
+	}
+	return float64(matches) / minHashSize
+}
+
+// minHash returns nil for code too short to hold a single shingle, which would otherwise
+// leave every slot at its initial value and match any other short code
+func minHash(code []byte) []uint32 {
+	// Push data is skipped so only the shape of the code counts
+	var opcodes []byte
+	for pc := 0; pc < len(code); pc++ {
+		op := vm.OpCode(code[pc])
+		opcodes = append(opcodes, byte(op))
+		if op.IsPush() {
+			pc += int(op - vm.PUSH1 + 1)
+		}
+	}
+
+	if len(opcodes) < shingleLength {
+		return nil
+	}
+
+	signature := make([]uint32, minHashSize)
+	for i := range signature {
+		signature[i] = math.MaxUint32
+	}
+	for start := 0; start+shingleLength <= len(opcodes); start++ {
+		h := fnv.New64a()
+		h.Write(opcodes[start : start+shingleLength])
+		shingle := h.Sum64()
+		for i, seed := range minHashSeeds {
+			if v := uint32((shingle*seed[0] + seed[1]) >> 32); v < signature[i] {
+				signature[i] = v
+			}
+		}
+	}
+	return signature
+}
@@ -0,0 +1,130 @@
+package contracts
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// metadata is a minimal CBOR map suffix followed by its length, as solc appends it
+var metadata = []byte{0xa1, 0x64, 's', 'o', 'l', 'c', 0x43, 0x00, 0x08, 0x13, 0x00, 0x0a}
+
+func push(op vm.OpCode, data ...byte) []byte {
+	return append([]byte{byte(op)}, data...)
+}
+
+func concat(parts ...[]byte) []byte {
+	var code []byte
+	for _, part := range parts {
+		code = append(code, part...)
+	}
+	return code
+}
+
+func TestNormalizeBytecode(t *testing.T) {
+	address := bytes.Repeat([]byte{0x11}, 20)
+	amount := append(make([]byte, 28), 0x01, 0x02, 0x03, 0x04)
+	topic := bytes.Repeat([]byte{0xdd}, 32)
+
+	tests := []struct {
+		name string
+		code []byte
+		want []byte
+	}{
+		{
+			name: "PUSH20 zeroed",
+			code: concat(push(vm.PUSH20, address...), []byte{byte(vm.POP)}),
+			want: concat(push(vm.PUSH20, make([]byte, 20)...), []byte{byte(vm.POP)}),
+		},
+		{
+			name: "PUSH32 with leading zeros zeroed",
+			code: concat(push(vm.PUSH32, amount...), []byte{byte(vm.POP)}),
+			want: concat(push(vm.PUSH32, make([]byte, 32)...), []byte{byte(vm.POP)}),
+		},
+		{
+			name: "PUSH32 topic kept",
+			code: concat(push(vm.PUSH32, topic...), []byte{byte(vm.POP)}),
+			want: concat(push(vm.PUSH32, topic...), []byte{byte(vm.POP)}),
+		},
+		{
+			name: "other pushes kept",
+			code: concat(push(vm.PUSH4, 0xa9, 0x05, 0x9c, 0xbb), push(vm.PUSH1, 0x14)),
+			want: concat(push(vm.PUSH4, 0xa9, 0x05, 0x9c, 0xbb), push(vm.PUSH1, 0x14)),
+		},
+		{
+			name: "push data that looks like PUSH20 is not an opcode",
+			code: concat(push(vm.PUSH1, byte(vm.PUSH20)), []byte{byte(vm.POP)}),
+			want: concat(push(vm.PUSH1, byte(vm.PUSH20)), []byte{byte(vm.POP)}),
+		},
+		{
+			name: "metadata stripped",
+			code: concat(push(vm.PUSH20, address...), []byte{byte(vm.STOP)}, metadata),
+			want: concat(push(vm.PUSH20, make([]byte, 20)...), []byte{byte(vm.STOP)}),
+		},
+		{
+			name: "truncated push at the end",
+			code: concat([]byte{byte(vm.STOP)}, push(vm.PUSH20, 0x11, 0x22)),
+			want: concat([]byte{byte(vm.STOP)}, push(vm.PUSH20, 0, 0)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := append([]byte(nil), tt.code...)
+			got := NormalizeBytecode(tt.code)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got %x, want %x", got, tt.want)
+			}
+			if !bytes.Equal(tt.code, original) {
+				t.Errorf("input modified to %x", tt.code)
+			}
+		})
+	}
+}
+
+// opcodeRun returns n pseudo-random arithmetic and stack opcodes, so few shingles repeat
+func opcodeRun(n int, seed uint32) []byte {
+	ops := []vm.OpCode{vm.ADD, vm.MUL, vm.SUB, vm.DIV, vm.AND, vm.OR, vm.XOR, vm.NOT, vm.DUP1, vm.SWAP1, vm.POP, vm.MLOAD, vm.MSTORE, vm.SLOAD, vm.SSTORE, vm.EQ}
+	code := make([]byte, n)
+	for i := range code {
+		seed = seed*1664525 + 1013904223
+		code[i] = byte(ops[seed>>28])
+	}
+	return code
+}
+
+func TestSimilarity(t *testing.T) {
+	long := opcodeRun(400, 1)
+	edited := append(append([]byte(nil), long...), opcodeRun(20, 2)...)
+	unrelated := opcodeRun(400, 3)
+	short := []byte{byte(vm.PUSH1), 0x01, byte(vm.STOP)}
+	otherShort := []byte{byte(vm.PUSH1), 0x02, byte(vm.ADD), byte(vm.STOP)}
+
+	tests := []struct {
+		name     string
+		a, b     []byte
+		min, max float64
+	}{
+		{"same code", long, long, 1, 1},
+		{"clone with another address", push(vm.PUSH20, bytes.Repeat([]byte{1}, 20)...), push(vm.PUSH20, bytes.Repeat([]byte{2}, 20)...), 1, 1},
+		{"lightly edited", long, edited, 0.5, 0.99},
+		{"unrelated", long, unrelated, 0, 0.3},
+		{"two short contracts", short, otherShort, 0, 0},
+		{"short and long", short, long, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Similarity(FingerprintBytecode(tt.a), FingerprintBytecode(tt.b))
+			if got < tt.min || got > tt.max {
+				t.Errorf("got %v, want between %v and %v", got, tt.min, tt.max)
+			}
+		})
+	}
+
+	if Similarity(nil, FingerprintBytecode(long)) != 0 {
+		t.Error("got non-zero similarity with a nil fingerprint")
+	}
+	if fingerprint := FingerprintBytecode(short); fingerprint.MinHash != nil {
+		t.Errorf("got MinHash %v for code shorter than a shingle, want nil", fingerprint.MinHash)
+	}
+}
@@ -0,0 +1,256 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	The owner is looked up, in order, through:
+
+		1. owner() and getOwner()
+		2. the newOwner of the last OwnershipTransferred event
+		3. the ERC-7201 OwnableUpgradeable slot, then the first ownerSlotCount slots holding
+		   something that looks like a real address. Tokens that hide their owner have no
+		   getter and emit no events, so this is a best guess and labelled as such.
+
+	The low slots also hold the supply, decimals, fees and packed flags, and any number
+	below 2^160 fits in the low 20 bytes of a word. A guess is only taken when the upper 4
+	bytes of the address are set, which a number that small never has, or when the
+	address has code, a nonce or a balance, which covers vanity addresses.
+
+	Ownership counts as renounced when the owner is the zero or dead address. A contract
+	owner is classified as a multisig when it answers getThreshold() like a Safe, and as a
+	timelock when it answers getMinDelay() (OpenZeppelin) or delay() (Compound).
+*/
+
+const ownerSlotCount = 6
+
+// ownableStorageSlot is where OpenZeppelin's OwnableUpgradeable v5 keeps the owner
+var ownableStorageSlot = common.HexToHash("0x9016d09d72d40fdae2fd8ceac6b6234c7706214fd39c1cd1e609a0528c199300")
+
+func GetOwnership(cl *ethclient.Client, tokenAddress common.Address, creationBlock uint64) (*types.Ownership, error) {
+	ownableABI, err := abi.JSON(strings.NewReader(utils.OwnableABI))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to parse OwnableABI: %s", err.Error())
+	}
+	token := bind.NewBoundContract(tokenAddress, ownableABI, cl, cl, cl)
+
+	ownership := &types.Ownership{}
+	ownership.Transfers, err = getOwnershipTransfers(cl, ownableABI, tokenAddress, creationBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, method := range []string{"owner", "getOwner"} {
+		if owner, ok := callAddress(token, method); ok {
+			ownership.Owner, ownership.Source = owner, method+"()"
+			break
+		}
+	}
+	if ownership.Source == "" && len(ownership.Transfers) > 0 {
+		ownership.Owner = ownership.Transfers[len(ownership.Transfers)-1].NewOwner
+		ownership.Source = "OwnershipTransferred"
+	}
+	if ownership.Source == "" {
+		ownership.Owner, ownership.Source, err = findOwnerInStorage(cl, tokenAddress)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if ownership.Source == "" {
+		ownership.OwnerKind = types.OwnerKindNone
+		return ownership, nil
+	}
+
+	if isBurnAddress(ownership.Owner) {
+		ownership.Renounced = true
+		ownership.OwnerKind = types.OwnerKindNone
+		for i := len(ownership.Transfers) - 1; i >= 0; i-- {
+			if isBurnAddress(ownership.Transfers[i].NewOwner) {
+				ownership.RenouncedAt = ownership.Transfers[i]
+				break
+			}
+		}
+		return ownership, nil
+	}
+
+	if err := classifyOwner(cl, ownership); err != nil {
+		return nil, err
+	}
+	return ownership, nil
+}
+
+func getOwnershipTransfers(cl *ethclient.Client, ownableABI abi.ABI, tokenAddress common.Address, creationBlock uint64) ([]*types.OwnershipTransfer, error) {
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block number: %s", err.Error())
+	}
+	eventID := ownableABI.Events["OwnershipTransferred"].ID
+	logs, err := utils.FilterLogs(cl, []common.Address{tokenAddress}, [][]common.Hash{{eventID}}, creationBlock, blockNum)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get OwnershipTransferred logs:\n\tToken Address: %s\n\tError: %s", tokenAddress, err.Error())
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	var transfers []*types.OwnershipTransfer
+	for _, log := range logs {
+		if len(log.Topics) != 3 {
+			continue
+		}
+		timestamp, err := utils.GetBlockTime(cl, log.BlockNumber, blockTimes)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, &types.OwnershipTransfer{
+			BlockNumber:   log.BlockNumber,
+			Timestamp:     timestamp,
+			TxHash:        log.TxHash,
+			PreviousOwner: common.BytesToAddress(log.Topics[1].Bytes()),
+			NewOwner:      common.BytesToAddress(log.Topics[2].Bytes()),
+		})
+	}
+	return transfers, nil
+}
+
+func findOwnerInStorage(cl *ethclient.Client, tokenAddress common.Address) (common.Address, string, error) {
+	slots := []common.Hash{ownableStorageSlot}
+	for i := int64(0); i < ownerSlotCount; i++ {
+		slots = append(slots, common.BigToHash(big.NewInt(i)))
+	}
+	ignored := map[common.Address]bool{
+		tokenAddress:                true,
+		utils.UniswapRouterAddress:  true,
+		utils.UniswapFactoryAddress: true,
+		utils.WETHAddress:           true,
+	}
+
+	for _, slot := range slots {
+		value, err := cl.StorageAt(context.Background(), tokenAddress, slot, nil)
+		if err != nil {
+			return common.Address{}, "", fmt.Errorf("\nFailed to read storage slot %s: %s", slot, err.Error())
+		}
+		word := common.BytesToHash(value)
+		address := common.BytesToAddress(word.Bytes())
+		if common.BytesToHash(address.Bytes()) != word || address == (common.Address{}) || ignored[address] {
+			continue
+		}
+		if slot != ownableStorageSlot {
+			plausible, err := looksLikeAddress(cl, address)
+			if err != nil {
+				return common.Address{}, "", err
+			}
+			if !plausible {
+				continue
+			}
+		}
+		source := "storage slot " + new(big.Int).SetBytes(slot.Bytes()).String() + " (best guess)"
+		if slot == ownableStorageSlot {
+			source = "OwnableUpgradeable storage slot"
+		}
+		return address, source, nil
+	}
+	return common.Address{}, "", nil
+}
+
+// looksLikeAddress tells an address stored in a slot apart from a small number
+func looksLikeAddress(cl *ethclient.Client, address common.Address) (bool, error) {
+	if address[0]|address[1]|address[2]|address[3] != 0 {
+		return true, nil
+	}
+	nonce, err := cl.NonceAt(context.Background(), address, nil)
+	if err != nil {
+		return false, fmt.Errorf("\nFailed to get nonce of %s: %s", address, err.Error())
+	}
+	if nonce > 0 {
+		return true, nil
+	}
+	balance, err := cl.BalanceAt(context.Background(), address, nil)
+	if err != nil {
+		return false, fmt.Errorf("\nFailed to get balance of %s: %s", address, err.Error())
+	}
+	if balance.Sign() > 0 {
+		return true, nil
+	}
+	code, err := cl.CodeAt(context.Background(), address, nil)
+	if err != nil {
+		return false, fmt.Errorf("\nFailed to get code at %s: %s", address, err.Error())
+	}
+	return len(code) > 0, nil
+}
+
+func classifyOwner(cl *ethclient.Client, ownership *types.Ownership) error {
+	code, err := cl.CodeAt(context.Background(), ownership.Owner, nil)
+	if err != nil {
+		return fmt.Errorf("\nFailed to get code at %s: %s", ownership.Owner, err.Error())
+	}
+	if len(code) == 0 {
+		ownership.OwnerKind = types.OwnerKindEOA
+		return nil
+	}
+
+	safeABI, err := abi.JSON(strings.NewReader(utils.GnosisSafeABI))
+	if err != nil {
+		return fmt.Errorf("\nFailed to parse GnosisSafeABI: %s", err.Error())
+	}
+	safe := bind.NewBoundContract(ownership.Owner, safeABI, cl, cl, cl)
+	if threshold, ok := callUint(safe, "getThreshold"); ok {
+		ownership.OwnerKind = types.OwnerKindMultisig
+		ownership.MultisigThreshold = threshold.Uint64()
+		var result []interface{}
+		if err := safe.Call(&bind.CallOpts{}, &result, "getOwners"); err == nil {
+			ownership.MultisigOwners = len(result[0].([]common.Address))
+		}
+		return nil
+	}
+
+	timelockABI, err := abi.JSON(strings.NewReader(utils.TimelockABI))
+	if err != nil {
+		return fmt.Errorf("\nFailed to parse TimelockABI: %s", err.Error())
+	}
+	timelock := bind.NewBoundContract(ownership.Owner, timelockABI, cl, cl, cl)
+	for _, method := range []string{"getMinDelay", "delay"} {
+		if delay, ok := callUint(timelock, method); ok {
+			ownership.OwnerKind = types.OwnerKindTimelock
+			ownership.TimelockDelay = time.Duration(delay.Int64()) * time.Second
+			return nil
+		}
+	}
+
+	ownership.OwnerKind = types.OwnerKindContract
+	return nil
+}
+
+// callAddress calls a view function returning an address, reporting false when the
+// contract doesn't implement it
+func callAddress(contract *bind.BoundContract, method string) (common.Address, bool) {
+	var result []interface{}
+	if err := contract.Call(&bind.CallOpts{}, &result, method); err != nil || len(result) == 0 {
+		return common.Address{}, false
+	}
+	address, ok := result[0].(common.Address)
+	return address, ok
+}
+
+func callUint(contract *bind.BoundContract, method string) (*big.Int, bool) {
+	var result []interface{}
+	if err := contract.Call(&bind.CallOpts{}, &result, method); err != nil || len(result) == 0 {
+		return nil, false
+	}
+	value, ok := result[0].(*big.Int)
+	return value, ok
+}
+
+func isBurnAddress(address common.Address) bool {
+	return address == (common.Address{}) || address == utils.DeadAddress
+}
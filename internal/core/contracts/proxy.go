@@ -0,0 +1,93 @@
+package contracts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+var (
+	eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	eip1967BeaconSlot         = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+	eip1967AdminSlot          = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	eip1822ProxiableSlot      = common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7")
+	zeppelinOSSlot            = common.HexToHash("0x7050c9e0f4ca769c69bd3a8ef740bc37934f8e2c036e5a723fd8ee048ed3f8c3")
+
+	// EIP-1167 minimal proxy runtime code, around the 20 byte implementation address
+	minimalProxyPrefix = common.FromHex("0x363d3d373d3d3d363d73")
+	minimalProxySuffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+)
+
+// GetProxyInfo checks the standard proxy storage slots and the EIP-1167 code pattern,
+// returning nil when the contract isn't a recognizable proxy
+func GetProxyInfo(cl *ethclient.Client, contractAddress common.Address) (*types.ProxyInfo, error) {
+	code, err := cl.CodeAt(context.Background(), contractAddress, nil)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get code at %s: %s", contractAddress, err.Error())
+	}
+	if len(code) == len(minimalProxyPrefix)+20+len(minimalProxySuffix) &&
+		bytes.HasPrefix(code, minimalProxyPrefix) && bytes.HasSuffix(code, minimalProxySuffix) {
+		return &types.ProxyInfo{
+			Kind:           "eip1167",
+			Implementation: common.BytesToAddress(code[len(minimalProxyPrefix) : len(minimalProxyPrefix)+20]),
+		}, nil
+	}
+
+	readAddress := func(slot common.Hash) (common.Address, error) {
+		value, err := cl.StorageAt(context.Background(), contractAddress, slot, nil)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("\nFailed to read storage slot %s: %s", slot, err.Error())
+		}
+		return common.BytesToAddress(value), nil
+	}
+
+	proxy := &types.ProxyInfo{Upgradeable: true}
+	for _, candidate := range []struct {
+		kind string
+		slot common.Hash
+	}{
+		{"eip1967", eip1967ImplementationSlot},
+		{"eip1822", eip1822ProxiableSlot},
+		{"zeppelinos", zeppelinOSSlot},
+	} {
+		implementation, err := readAddress(candidate.slot)
+		if err != nil {
+			return nil, err
+		}
+		if implementation != (common.Address{}) {
+			proxy.Kind, proxy.Implementation = candidate.kind, implementation
+			break
+		}
+	}
+
+	if proxy.Kind == "" {
+		beacon, err := readAddress(eip1967BeaconSlot)
+		if err != nil {
+			return nil, err
+		} else if beacon == (common.Address{}) {
+			return nil, nil
+		}
+		beaconABI, err := abi.JSON(strings.NewReader(utils.BeaconABI))
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to parse BeaconABI: %s", err.Error())
+		}
+		proxy.Kind = "eip1967-beacon"
+		if implementation, ok := callAddress(bind.NewBoundContract(beacon, beaconABI, cl, cl, cl), "implementation"); ok {
+			proxy.Implementation = implementation
+		}
+	}
+
+	proxy.Admin, err = readAddress(eip1967AdminSlot)
+	if err != nil {
+		return nil, err
+	}
+	return proxy, nil
+}
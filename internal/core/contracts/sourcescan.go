@@ -0,0 +1,499 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+/*
+	Verified source is scanned with a handful of targeted patterns rather than a full
+	Solidity parser. Comments are blanked out first (keeping line numbers) so commented
+	code doesn't match, then function and library bodies are located by brace matching:
+
+		owner-transfer-branch	an if in the transfer path that checks the owner, tx.origin
+					or a hard-coded address
+		blacklist-mapping	an address => bool mapping named like a blacklist or bot list
+		uncapped-fee		a public setter that assigns a fee or tax from a parameter
+					without comparing it against a limit
+		hardcoded-exempt	a mapping entry set to true for a literal address
+		obfuscated-call		addresses built from integers, hard-coded selectors or raw
+					calldata, and calls from inline assembly
+
+	Bundled OpenZeppelin files and library blocks are skipped since their low level calls
+	are expected. Social links are taken from the comments of the remaining files.
+*/
+
+const (
+	PatternOwnerTransferBranch = "owner-transfer-branch"
+	PatternBlacklistMapping    = "blacklist-mapping"
+	PatternUncappedFee         = "uncapped-fee"
+	PatternHardcodedExempt     = "hardcoded-exempt"
+	PatternObfuscatedCall      = "obfuscated-call"
+)
+
+// transferFunctions are the functions whose branches decide who can move tokens
+var transferFunctions = map[string]bool{
+	"_transfer":            true,
+	"transfer":             true,
+	"transferFrom":         true,
+	"_tokenTransfer":       true,
+	"_update":              true,
+	"_beforeTokenTransfer": true,
+	"_afterTokenTransfer":  true,
+}
+
+var (
+	functionRegex   = regexp.MustCompile(`\b(function\s+(\w+)|constructor|modifier\s+(\w+))\s*\(`)
+	libraryRegex    = regexp.MustCompile(`\blibrary\s+\w+`)
+	assemblyRegex   = regexp.MustCompile(`\bassembly\s*(\("[^"]*"\)\s*)?\{`)
+	conditionRegex  = regexp.MustCompile(`\b(if|require|assert)\s*\(`)
+	privilegedRegex = regexp.MustCompile(`owner\s*\(\s*\)|\b_?owner\b|tx\.origin|0x[0-9a-fA-F]{40}`)
+	comparisonRegex = regexp.MustCompile(`[<>]`)
+	mappingRegex    = regexp.MustCompile(`mapping\s*\(\s*address\s*=>\s*bool\s*\)\s*(?:(?:public|private|internal)\s+)*(\w+)`)
+	blacklistRegex  = regexp.MustCompile(`(?i)black|block|bot|snip|ban|deny|frozen|freeze`)
+	feeAssignRegex  = regexp.MustCompile(`\b(\w*(?i:fee|tax)\w*)\s*(?:\[[^\]]*\]\s*)?=\s*([^=;][^;]*);`)
+	feeAddressRegex = regexp.MustCompile(`(?i)receiver|recipient|wallet|address|exclud|exempt`)
+	exemptRegex     = regexp.MustCompile(`\b(\w+)\s*\[\s*(?:address\s*\(\s*)?(0x[0-9a-fA-F]{40})\s*\)?\s*\]\s*=\s*true\b`)
+	addressIntRegex = regexp.MustCompile(`address\s*\(\s*uint160\s*\(`)
+	selectorRegex   = regexp.MustCompile(`abi\.encodeWithSelector\s*\(\s*(?:bytes4\s*\(\s*)?0x[0-9a-fA-F]{8}\b`)
+	rawCallRegex    = regexp.MustCompile(`\.(?:call|delegatecall|staticcall)\s*(?:\{[^}]*\}\s*)?\(\s*(?:abi\.encodePacked\s*\(\s*)?(?:hex"|0x[0-9a-fA-F]{8})`)
+	asmCallRegex    = regexp.MustCompile(`\b(call|delegatecall|staticcall|callcode)\s*\(`)
+
+	socialRegex  = regexp.MustCompile(`(?i)(?:https?://)?(?:www\.)?\b(?:t\.me|telegram\.me|twitter\.com|x\.com)/[A-Za-z0-9_+]+`)
+	urlRegex     = regexp.MustCompile(`(?i)https?://[^\s"'<>()\[\]]+`)
+	websiteRegex = regexp.MustCompile(`(?i)\b(?:website|web|site)\s*:\s*([a-z0-9.-]+\.[a-z]{2,}[^\s"'<>()]*)`)
+)
+
+// ignoredLinkHosts are documentation links found in bundled library comments
+var ignoredLinkHosts = []string{
+	"github.com/openzeppelin",
+	"forum.openzeppelin.com",
+	"blog.openzeppelin.com",
+	"docs.openzeppelin.com",
+	"eips.ethereum.org",
+	"ethereum.github.io",
+	"ethereum.org",
+	"docs.soliditylang.org",
+	"solidity.readthedocs.io",
+	"consensys.github.io",
+	"diligence.consensys.net",
+	"en.wikipedia.org",
+	"spdx.org",
+	"docs.uniswap.org",
+	"github.com/ethereum",
+}
+
+type sourceFile struct {
+	path    string
+	content string
+}
+
+type codeBlock struct {
+	name       string
+	header     string
+	params     []string
+	start, end int
+}
+
+// ScanSource looks for scam patterns and social links in a verified contract's source,
+// returning nil when the source isn't available
+func ScanSource(source *types.ContractSource) (*types.SourceReport, error) {
+	if source == nil || !source.Verified {
+		return nil, nil
+	}
+	files, err := splitSourceFiles(source)
+	if err != nil {
+		return nil, fmt.Errorf("\nsplitSourceFiles() failed:\n\tContract Address: %s\n\tError: %v", source.Address, err)
+	}
+
+	report := &types.SourceReport{Files: len(files)}
+	seenLinks := make(map[string]bool)
+	for _, file := range files {
+		if isBundledLibrary(file.path) {
+			continue
+		}
+		scanFile(file, report, seenLinks)
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		a, b := report.Findings[i], report.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return report, nil
+}
+
+// splitSourceFiles handles the three shapes explorers return source in: plain Solidity,
+// a JSON object of files, and a standard JSON compiler input wrapped in double braces
+func splitSourceFiles(source *types.ContractSource) ([]sourceFile, error) {
+	code := strings.TrimSpace(source.SourceCode)
+	if !strings.HasPrefix(code, "{") {
+		return []sourceFile{{path: source.ContractName + ".sol", content: source.SourceCode}}, nil
+	}
+
+	var sources map[string]struct {
+		Content string `json:"content"`
+	}
+	if strings.HasPrefix(code, "{{") && strings.HasSuffix(code, "}}") {
+		var input struct {
+			Sources map[string]struct {
+				Content string `json:"content"`
+			} `json:"sources"`
+		}
+		if err := json.Unmarshal([]byte(code[1:len(code)-1]), &input); err != nil {
+			return nil, fmt.Errorf("\nFailed to decode standard JSON input: %v", err)
+		}
+		sources = input.Sources
+	} else if err := json.Unmarshal([]byte(code), &sources); err != nil {
+		// Not JSON after all, a file that happens to start with a brace
+		return []sourceFile{{path: source.ContractName + ".sol", content: source.SourceCode}}, nil
+	}
+
+	var files []sourceFile
+	for path, file := range sources {
+		files = append(files, sourceFile{path: path, content: file.Content})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files, nil
+}
+
+func isBundledLibrary(path string) bool {
+	return strings.Contains(path, "@openzeppelin/") || strings.Contains(path, "node_modules/")
+}
+
+func scanFile(file sourceFile, report *types.SourceReport, seenLinks map[string]bool) {
+	code, comments := blankComments(file.content)
+	lines := strings.Split(file.content, "\n")
+	libraries := findBlocks(code, libraryRegex)
+	functions := findBlocks(code, functionRegex)
+
+	add := func(pattern string, offset int, detail string) {
+		for _, library := range libraries {
+			if offset >= library.start && offset < library.end {
+				return
+			}
+		}
+		line := strings.Count(code[:offset], "\n") + 1
+		snippet := strings.TrimSpace(lines[line-1])
+		if len(snippet) > 120 {
+			snippet = snippet[:117] + "..."
+		}
+		report.Findings = append(report.Findings, types.SourceFinding{
+			Pattern: pattern,
+			File:    file.path,
+			Line:    line,
+			Snippet: snippet,
+			Detail:  detail,
+		})
+	}
+
+	for _, fn := range functions {
+		body := code[fn.start:fn.end]
+
+		if transferFunctions[fn.name] {
+			for _, loc := range conditionRegex.FindAllStringSubmatchIndex(body, -1) {
+				if body[loc[2]:loc[3]] != "if" {
+					continue
+				}
+				condition := balancedParens(body, loc[1]-1)
+				if match := privilegedRegex.FindString(condition); match != "" {
+					add(PatternOwnerTransferBranch, fn.start+loc[0], fmt.Sprintf("%s branches on %s", fn.name, match))
+				}
+			}
+		}
+
+		if fn.name != "constructor" && (strings.Contains(fn.header, "external") || strings.Contains(fn.header, "public")) {
+			for _, loc := range feeAssignRegex.FindAllStringSubmatchIndex(body, -1) {
+				variable, value := body[loc[2]:loc[3]], body[loc[4]:loc[5]]
+				if feeAddressRegex.MatchString(variable) || strings.Contains(value, "address(") {
+					continue
+				}
+				param := usedParam(value, fn.params)
+				if param == "" || isCapped(body, variable, param) {
+					continue
+				}
+				add(PatternUncappedFee, fn.start+loc[0], fmt.Sprintf("%s sets %s from %s without a limit", fn.name, variable, param))
+			}
+		}
+	}
+
+	for _, loc := range mappingRegex.FindAllStringSubmatchIndex(code, -1) {
+		name := code[loc[2]:loc[3]]
+		if blacklistRegex.MatchString(name) {
+			add(PatternBlacklistMapping, loc[0], "address list "+name)
+		}
+	}
+	for _, loc := range exemptRegex.FindAllStringSubmatchIndex(code, -1) {
+		add(PatternHardcodedExempt, loc[0], fmt.Sprintf("%s[%s] set to true", code[loc[2]:loc[3]], code[loc[4]:loc[5]]))
+	}
+
+	for _, loc := range addressIntRegex.FindAllStringIndex(code, -1) {
+		add(PatternObfuscatedCall, loc[0], "address built from an integer")
+	}
+	for _, loc := range selectorRegex.FindAllStringIndex(code, -1) {
+		add(PatternObfuscatedCall, loc[0], "call data with a hard-coded selector")
+	}
+	for _, loc := range rawCallRegex.FindAllStringIndex(code, -1) {
+		add(PatternObfuscatedCall, loc[0], "low level call with raw call data")
+	}
+	for _, block := range findBlocks(code, assemblyRegex) {
+		body := code[block.start:block.end]
+		for _, loc := range asmCallRegex.FindAllStringSubmatchIndex(body, -1) {
+			add(PatternObfuscatedCall, block.start+loc[0], body[loc[2]:loc[3]]+" from inline assembly")
+		}
+	}
+
+	for _, comment := range comments {
+		for _, link := range extractLinks(comment.text) {
+			key := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(link.URL, "https://"), "http://"))
+			if seenLinks[key] {
+				continue
+			}
+			seenLinks[key] = true
+			link.File = file.path
+			link.Line = comment.line
+			report.Links = append(report.Links, link)
+		}
+	}
+}
+
+type sourceComment struct {
+	line int
+	text string
+}
+
+// blankComments replaces comments with spaces, keeping newlines so offsets still map to
+// the same lines, and blanks braces and parentheses inside string literals so they don't
+// upset the block matching. Each comment line is returned separately.
+func blankComments(content string) (string, []sourceComment) {
+	code := []byte(content)
+	var comments []sourceComment
+	line := 1
+	var comment []byte
+	endComment := func() {
+		if len(comment) > 0 {
+			comments = append(comments, sourceComment{line: line, text: string(comment)})
+		}
+		comment = comment[:0]
+	}
+
+	for i := 0; i < len(code); i++ {
+		switch {
+		case code[i] == '/' && i+1 < len(code) && code[i+1] == '/':
+			for ; i < len(code) && code[i] != '\n'; i++ {
+				comment = append(comment, code[i])
+				code[i] = ' '
+			}
+			endComment()
+			i--
+		case code[i] == '/' && i+1 < len(code) && code[i+1] == '*':
+			code[i], code[i+1] = ' ', ' '
+			for i += 2; i < len(code) && !(code[i] == '*' && i+1 < len(code) && code[i+1] == '/'); i++ {
+				if code[i] == '\n' {
+					endComment()
+					line++
+					continue
+				}
+				comment = append(comment, code[i])
+				code[i] = ' '
+			}
+			endComment()
+			if i+1 < len(code) {
+				code[i], code[i+1] = ' ', ' '
+				i++
+			}
+		case code[i] == '"' || code[i] == '\'':
+			quote := code[i]
+			for i++; i < len(code) && code[i] != quote && code[i] != '\n'; i++ {
+				switch code[i] {
+				case '\\':
+					i++
+				case '{', '}', '(', ')', ';':
+					code[i] = ' '
+				}
+			}
+		case code[i] == '\n':
+			line++
+		}
+	}
+	return string(code), comments
+}
+
+// findBlocks returns the brace delimited body following each match of re, skipping
+// declarations that end in a semicolon before any body starts
+func findBlocks(code string, re *regexp.Regexp) []codeBlock {
+	var blocks []codeBlock
+	for _, loc := range re.FindAllStringSubmatchIndex(code, -1) {
+		open := strings.IndexAny(code[loc[1]-1:], "{;")
+		if open < 0 {
+			continue
+		}
+		open += loc[1] - 1
+		if code[open] == ';' {
+			continue
+		}
+		end := matchingBrace(code, open)
+		if end < 0 {
+			continue
+		}
+
+		block := codeBlock{header: code[loc[0]:open], start: open, end: end}
+		if strings.HasPrefix(block.header, "constructor") {
+			block.name = "constructor"
+		}
+		// Later submatches hold the function or modifier name
+		for g := 4; g+1 < len(loc); g += 2 {
+			if loc[g] >= 0 {
+				block.name = code[loc[g]:loc[g+1]]
+			}
+		}
+		if paren := strings.Index(block.header, "("); paren >= 0 {
+			block.params = paramNames(balancedParens(code, loc[0]+paren))
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func matchingBrace(code string, open int) int {
+	depth := 0
+	for i := open; i < len(code); i++ {
+		switch code[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// balancedParens returns the text between the parenthesis at open and its match
+func balancedParens(code string, open int) string {
+	if open < 0 || open >= len(code) || code[open] != '(' {
+		return ""
+	}
+	depth := 0
+	for i := open; i < len(code); i++ {
+		switch code[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return code[open+1 : i]
+			}
+		}
+	}
+	return ""
+}
+
+// paramNames takes the last word of each comma separated parameter declaration
+func paramNames(params string) []string {
+	var names []string
+	for _, param := range strings.Split(params, ",") {
+		fields := strings.Fields(param)
+		if len(fields) >= 2 {
+			names = append(names, fields[len(fields)-1])
+		}
+	}
+	return names
+}
+
+// usedParam returns the first parameter that appears as a whole word in expression
+func usedParam(expression string, params []string) string {
+	for _, param := range params {
+		if containsWord(expression, param) {
+			return param
+		}
+	}
+	return ""
+}
+
+func containsWord(s, word string) bool {
+	if word == "" {
+		return false
+	}
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		if (start == 0 || !isIdentByte(s[start-1])) && (end == len(s) || !isIdentByte(s[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '$' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// isCapped reports whether the function compares the fee variable or the parameter it
+// was set from against anything, in a require, assert or if
+func isCapped(body, variable, param string) bool {
+	for _, loc := range conditionRegex.FindAllStringIndex(body, -1) {
+		condition := balancedParens(body, loc[1]-1)
+		if !comparisonRegex.MatchString(condition) {
+			continue
+		}
+		if strings.Contains(condition, variable) || usedParam(condition, []string{param}) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func extractLinks(comment string) []types.SocialLink {
+	var links []types.SocialLink
+	seen := make(map[string]bool)
+	addLink := func(kind, url string) {
+		url = strings.TrimRight(url, ".,;:!*/")
+		lower := strings.ToLower(url)
+		for _, host := range ignoredLinkHosts {
+			if strings.Contains(lower, host) {
+				return
+			}
+		}
+		if seen[lower] {
+			return
+		}
+		seen[lower] = true
+		links = append(links, types.SocialLink{Kind: kind, URL: url})
+	}
+
+	socials := socialRegex.FindAllString(comment, -1)
+	for _, url := range socials {
+		kind := types.SocialTwitter
+		if strings.Contains(strings.ToLower(url), "t.me/") || strings.Contains(strings.ToLower(url), "telegram.me/") {
+			kind = types.SocialTelegram
+		}
+		if !strings.Contains(url, "://") {
+			url = "https://" + url
+		}
+		addLink(kind, url)
+	}
+	for _, url := range urlRegex.FindAllString(comment, -1) {
+		if !socialRegex.MatchString(url) {
+			addLink(types.SocialWebsite, url)
+		}
+	}
+	for _, match := range websiteRegex.FindAllStringSubmatch(comment, -1) {
+		if !strings.Contains(match[0], "://") && !socialRegex.MatchString(match[1]) {
+			addLink(types.SocialWebsite, "https://"+match[1])
+		}
+	}
+	return links
+}
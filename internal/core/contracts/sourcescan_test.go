@@ -0,0 +1,248 @@
+package contracts
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+func scanSnippet(t *testing.T, code string) *types.SourceReport {
+	t.Helper()
+	report, err := ScanSource(&types.ContractSource{Verified: true, ContractName: "Token", SourceCode: code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func findingPatterns(report *types.SourceReport) []string {
+	var patterns []string
+	for _, finding := range report.Findings {
+		patterns = append(patterns, finding.Pattern)
+	}
+	return patterns
+}
+
+func TestScanSourcePatterns(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want []string
+	}{
+		{
+			name: "owner branch in transfer",
+			code: `contract Token {
+	function _transfer(address from, address to, uint256 amount) internal {
+		if (from != owner()) { require(amount < 1); }
+	}
+}`,
+			want: []string{PatternOwnerTransferBranch},
+		},
+		{
+			name: "tx.origin branch in transfer",
+			code: `contract Token {
+	function transfer(address to, uint256 amount) public returns (bool) {
+		if (tx.origin == to) { return false; }
+		return true;
+	}
+}`,
+			want: []string{PatternOwnerTransferBranch},
+		},
+		{
+			name: "owner branch outside the transfer path",
+			code: `contract Token {
+	function withdraw() external {
+		if (msg.sender == owner()) { payable(msg.sender).transfer(1); }
+	}
+}`,
+			want: nil,
+		},
+		{
+			name: "blacklist mapping",
+			code: `contract Token {
+	mapping(address => bool) private _isBlacklisted;
+	mapping(address => bool) public isExcludedFromFee;
+}`,
+			want: []string{PatternBlacklistMapping},
+		},
+		{
+			name: "uncapped fee setter",
+			code: `contract Token {
+	uint256 public sellFee;
+	function setSellFee(uint256 newFee) external onlyOwner {
+		sellFee = newFee;
+	}
+}`,
+			want: []string{PatternUncappedFee},
+		},
+		{
+			name: "capped fee setter",
+			code: `contract Token {
+	uint256 public sellFee;
+	function setSellFee(uint256 newFee) external onlyOwner {
+		require(newFee <= 10, "too high");
+		sellFee = newFee;
+	}
+}`,
+			want: nil,
+		},
+		{
+			name: "fee parameter sharing a prefix is not used",
+			code: `contract Token {
+	uint256 public sellFee;
+	uint256 feeCap;
+	function setSellFee(uint256 fee) external onlyOwner {
+		sellFee = feeCap;
+	}
+}`,
+			want: nil,
+		},
+		{
+			name: "fee wallet setter",
+			code: `contract Token {
+	address public feeWallet;
+	function setFeeWallet(address wallet) external onlyOwner {
+		feeWallet = wallet;
+	}
+}`,
+			want: nil,
+		},
+		{
+			name: "hard-coded exemption",
+			code: `contract Token {
+	constructor() {
+		_isExcluded[0x1234567890123456789012345678901234567890] = true;
+	}
+}`,
+			want: []string{PatternHardcodedExempt},
+		},
+		{
+			name: "address built from an integer",
+			code: `contract Token {
+	function f() internal view returns (address) {
+		return address(uint160(0x1234));
+	}
+}`,
+			want: []string{PatternObfuscatedCall},
+		},
+		{
+			name: "call from inline assembly",
+			code: `contract Token {
+	function f(address target) internal {
+		assembly {
+			let ok := call(gas(), target, 0, 0, 0, 0, 0)
+		}
+	}
+}`,
+			want: []string{PatternObfuscatedCall},
+		},
+		{
+			name: "assembly call inside a library",
+			code: `library Address {
+	function f(address target) internal {
+		assembly {
+			let ok := call(gas(), target, 0, 0, 0, 0, 0)
+		}
+	}
+}`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findingPatterns(scanSnippet(t, tt.code))
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got patterns %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanSourceComments(t *testing.T) {
+	code := `contract Token {
+	// mapping(address => bool) private _blacklist;
+	/*
+	mapping(address => bool) private _bots;
+	*/
+	string constant NOTE = "mapping(address => bool) private _snipers; {";
+	mapping(address => bool) private _frozen;
+}`
+	report := scanSnippet(t, code)
+	if len(report.Findings) != 1 {
+		t.Fatalf("got findings %+v, want only the uncommented mapping", report.Findings)
+	}
+	if finding := report.Findings[0]; finding.Line != 7 || !strings.Contains(finding.Detail, "_frozen") {
+		t.Errorf("got %+v, want _frozen on line 7", finding)
+	}
+}
+
+func TestBlankCommentsKeepsOffsets(t *testing.T) {
+	content := "a // one\n/* two\nthree */ b"
+	code, comments := blankComments(content)
+	if len(code) != len(content) || strings.Count(code, "\n") != 2 {
+		t.Fatalf("blanked code %q changed length or lines", code)
+	}
+	if strings.Join(strings.Fields(code), " ") != "a b" || strings.Index(code, "b") != strings.LastIndex(content, "b") {
+		t.Errorf("got %q", code)
+	}
+	if len(comments) != 3 || comments[0].line != 1 || comments[1].line != 2 || comments[2].line != 3 {
+		t.Errorf("got comments %+v", comments)
+	}
+}
+
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    []types.SocialLink
+	}{
+		{"// Telegram: t.me/SomeToken", []types.SocialLink{{Kind: types.SocialTelegram, URL: "https://t.me/SomeToken"}}},
+		{"// https://twitter.com/some_token.", []types.SocialLink{{Kind: types.SocialTwitter, URL: "https://twitter.com/some_token"}}},
+		{"// Website: sometoken.io", []types.SocialLink{{Kind: types.SocialWebsite, URL: "https://sometoken.io"}}},
+		{"// https://sometoken.io/ and https://sometoken.io", []types.SocialLink{{Kind: types.SocialWebsite, URL: "https://sometoken.io"}}},
+		{"// See https://eips.ethereum.org/EIPS/eip-20", nil},
+		{"// no links here", nil},
+	}
+	for _, tt := range tests {
+		got := extractLinks(tt.comment)
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %+v, want %+v", tt.comment, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: got %+v, want %+v", tt.comment, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestScanSourceFiles(t *testing.T) {
+	files := map[string]map[string]string{
+		"contracts/Token.sol":                       {"content": "// t.me/SomeToken\ncontract Token { mapping(address => bool) bots; }"},
+		"@openzeppelin/contracts/utils/Address.sol": {"content": "// https://t.me/Other\ncontract A { mapping(address => bool) blocked; }"},
+	}
+	input, err := json.Marshal(map[string]interface{}{"language": "Solidity", "sources": files})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := scanSnippet(t, "{"+string(input)+"}")
+	if report.Files != 2 {
+		t.Errorf("got %d files, want 2", report.Files)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].File != "contracts/Token.sol" {
+		t.Errorf("got findings %+v, want one in contracts/Token.sol", report.Findings)
+	}
+	if len(report.Links) != 1 || report.Links[0].URL != "https://t.me/SomeToken" {
+		t.Errorf("got links %+v, want only the token's telegram", report.Links)
+	}
+}
+
+func TestScanSourceUnverified(t *testing.T) {
+	report, err := ScanSource(&types.ContractSource{})
+	if err != nil || report != nil {
+		t.Errorf("got %+v, %v, want nil report for unverified source", report, err)
+	}
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	A deployer's history is rebuilt from its nonce. Every contract it created directly
+	lives at CreateAddress(deployer, nonce), so each nonce used before the report block
+	is checked for code. Contracts the deployer created through a factory aren't found.
+
+	Tokens among those contracts count as rugged when their Uniswap V2 WETH pair now
+	holds less than (100 - rugDropPct)% of its peak WETH reserve (liquidity removed), or
+	the price sits that far below its peak (price collapse).
+
+	The address's age is taken from its first outgoing transaction, found by binary
+	searching for the first block with a non-zero nonce.
+*/
+
+const (
+	maxDeployerNonces = 500
+	rugDropPct        = 90
+)
+
+// GenerateDeployerReport reports on an address's history up to atBlock, or the latest
+// block when atBlock is zero, tracing its funding up to fundingHops hops
+func GenerateDeployerReport(ethNodeURL string, deployer common.Address, atBlock uint64, fundingHops int, addressLabels labels.Labels) (*types.DeployerReport, error) {
+	cl, err := ethclient.Dial(ethNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
+	}
+	if atBlock == 0 {
+		atBlock, err = cl.BlockNumber(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get block number: %v", err)
+		}
+	}
+	report, err := GetDeployerReport(cl, deployer, atBlock)
+	if err != nil {
+		return nil, err
+	}
+	report.Funding, err = TraceFunding(cl, deployer, atBlock, fundingHops, addressLabels)
+	if err != nil {
+		return nil, fmt.Errorf("\nTraceFunding() failed:\n\tDeployer: %s\n\tError: %v", deployer, err)
+	}
+	return report, nil
+}
+
+func GetDeployerReport(cl *ethclient.Client, deployer common.Address, atBlock uint64) (*types.DeployerReport, error) {
+	block := new(big.Int).SetUint64(atBlock)
+	nonce, err := cl.NonceAt(context.Background(), deployer, block)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get nonce of %s at block %d: %v", deployer, atBlock, err)
+	}
+	balance, err := cl.BalanceAt(context.Background(), deployer, block)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get balance of %s at block %d: %v", deployer, atBlock, err)
+	}
+
+	report := &types.DeployerReport{
+		Address: deployer,
+		AtBlock: atBlock,
+		Balance: utils.ToDecimal(balance, 18),
+		Nonce:   nonce,
+	}
+
+	if nonce > 0 {
+		report.FirstActivityBlock, err = utils.SearchBlocks(0, atBlock, func(blockNumber uint64) (bool, error) {
+			nonce, err := cl.NonceAt(context.Background(), deployer, new(big.Int).SetUint64(blockNumber))
+			if err != nil {
+				return false, fmt.Errorf("\nFailed to get nonce at block %d: %v", blockNumber, err)
+			}
+			return nonce > 0, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		firstActivity, err := utils.GetBlockTime(cl, report.FirstActivityBlock, nil)
+		if err != nil {
+			return nil, err
+		}
+		report.FirstActivity = &firstActivity
+	}
+
+	report.Contracts, err = findDeployedContracts(cl, deployer, nonce, block)
+	if err != nil {
+		return nil, err
+	}
+	for _, contract := range report.Contracts {
+		contract.IsToken, err = contracts.IsERC20Contract(cl, contract.Address)
+		if err != nil {
+			return nil, fmt.Errorf("\nIsERC20Contract() failed:\n\tContract Address: %s\n\tError: %v", contract.Address, err)
+		}
+		if !contract.IsToken {
+			continue
+		}
+		report.TokenCount++
+
+		// Tokens without name() or symbol() are still counted, just unnamed
+		if data, err := contracts.GetBasicContractData(cl, contract.Address); err == nil {
+			contract.Name, contract.Symbol = data.Name, data.Symbol
+		}
+		contract.Rugged, contract.RugReason, err = checkRugged(cl, contract.Address)
+		if err != nil {
+			return nil, fmt.Errorf("\ncheckRugged() failed:\n\tToken Address: %s\n\tError: %v", contract.Address, err)
+		}
+		if contract.Rugged {
+			report.RuggedCount++
+		}
+	}
+
+	return report, nil
+}
+
+// findDeployedContracts checks the addresses created by the deployer's most recent
+// nonces for code
+func findDeployedContracts(cl *ethclient.Client, deployer common.Address, nonce uint64, block *big.Int) ([]*types.DeployedContract, error) {
+	var first uint64
+	if nonce > maxDeployerNonces {
+		first = nonce - maxDeployerNonces
+	}
+
+	var deployed []*types.DeployedContract
+	for n := first; n < nonce; n++ {
+		address := crypto.CreateAddress(deployer, n)
+		code, err := cl.CodeAt(context.Background(), address, block)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get code at %s: %v", address, err)
+		}
+		if len(code) > 0 {
+			deployed = append(deployed, &types.DeployedContract{
+				Address: address,
+				Nonce:   n,
+			})
+		}
+	}
+	return deployed, nil
+}
+
+// checkRugged compares the current state of a token's pair to its peak
+func checkRugged(cl *ethclient.Client, tokenAddress common.Address) (bool, string, error) {
+	pair, err := dexes.GetUniswapPair(cl, tokenAddress)
+	if err != nil || pair == nil {
+		return false, "", err
+	}
+	creationBlock, err := utils.FindCreationBlock(cl, pair.Address)
+	if err != nil {
+		return false, "", err
+	}
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return false, "", fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+	history, err := dexes.GetReserveHistory(cl, pair, creationBlock, blockNum)
+	if err != nil || len(history) == 0 {
+		return false, "", err
+	}
+
+	var peakReserve, peakPrice *big.Float
+	for _, reserves := range history {
+		if peakReserve == nil || reserves.WETHReserve.Cmp(peakReserve) > 0 {
+			peakReserve = reserves.WETHReserve
+		}
+		if reserves.PriceInWETH != nil && (peakPrice == nil || reserves.PriceInWETH.Cmp(peakPrice) > 0) {
+			peakPrice = reserves.PriceInWETH
+		}
+	}
+	last := history[len(history)-1]
+
+	if dropPct := dropFromPeakPct(peakReserve, last.WETHReserve); dropPct >= rugDropPct {
+		return true, fmt.Sprintf("liquidity removed (%.1f%% below peak)", dropPct), nil
+	}
+	if last.PriceInWETH != nil {
+		if dropPct := dropFromPeakPct(peakPrice, last.PriceInWETH); dropPct >= rugDropPct {
+			return true, fmt.Sprintf("price collapsed (%.1f%% below peak)", dropPct), nil
+		}
+	}
+	return false, "", nil
+}
+
+func dropFromPeakPct(peak, current *big.Float) float64 {
+	if peak == nil || peak.Sign() == 0 {
+		return 0
+	}
+	drop := new(big.Float).Sub(peak, current)
+	dropPct, _ := new(big.Float).Quo(drop, peak).Float64()
+	return dropPct * 100
+}
+
+func PrintDeployerReport(report *types.DeployerReport) {
+	fmt.Printf("\nDeployer:               %s\n", report.Address)
+	fmt.Printf("At Block:               %d\n", report.AtBlock)
+	if report.FirstActivity != nil {
+		fmt.Printf("First Transaction:      %s (block %d)\n", report.FirstActivity.Format(time.RFC3339), report.FirstActivityBlock)
+	} else {
+		fmt.Println("First Transaction:      none")
+	}
+	fmt.Printf("Balance:                %s ETH\n", report.Balance.Text('f', 4))
+	fmt.Printf("Nonce:                  %d\n", report.Nonce)
+	fmt.Printf("Contracts Deployed:     %d\n", len(report.Contracts))
+	fmt.Printf("Tokens Deployed:        %d\n", report.TokenCount)
+	fmt.Printf("Tokens Rugged:          %d\n", report.RuggedCount)
+	if report.Funding != nil {
+		fmt.Printf("Funding Source:         %s\n", describeFundingSource(report.Funding))
+		printFundingTrace(report.Funding, "  ")
+	}
+	if report.Nonce > maxDeployerNonces {
+		fmt.Printf("Only the last %d of %d nonces were checked\n", maxDeployerNonces, report.Nonce)
+	}
+
+	if len(report.Contracts) == 0 {
+		return
+	}
+	fmt.Printf("\n%-6s %-42s %-6s %-12s %s\n", "Nonce", "Address", "Token", "Symbol", "Status")
+	for _, contract := range report.Contracts {
+		token, status := "no", ""
+		if contract.IsToken {
+			token, status = "yes", "ok"
+			if contract.Rugged {
+				status = "RUGGED: " + contract.RugReason
+			}
+		}
+		fmt.Printf("%-6d %-42s %-6s %-12s %s\n", contract.Nonce, contract.Address.Hex(), token, contract.Symbol, status)
+	}
+}
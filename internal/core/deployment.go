@@ -0,0 +1,201 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	The deployment report reads the token's deployment transaction twice over: its input
+	for the constructor arguments, and its receipt for the Transfer events that hand out
+	the initial supply. Tokens created by a factory have no constructor arguments of
+	their own in the input, so only the distribution is reported for them.
+
+	The distribution is each address's net change over the whole transaction, so supply
+	minted to the deployer and forwarded to a team wallet in the constructor shows up on
+	the team wallet.
+*/
+
+const (
+	AllocationDeployer = "deployer"
+	AllocationToken    = "token"
+	AllocationBurn     = "burn"
+	AllocationContract = "contract"
+	AllocationEOA      = "eoa"
+)
+
+// AnalyzeDeployment decodes the constructor arguments and initial distribution of a
+// token. source is used for the ABI and published arguments when it is the token's own.
+func AnalyzeDeployment(cl *ethclient.Client, tokenAddress common.Address, creation *types.ContractCreation, source *types.ContractSource, decimals uint8, addressLabels labels.Labels) (*types.DeploymentReport, error) {
+	if creation == nil || creation.TxHash == (common.Hash{}) {
+		return nil, nil
+	}
+	tx, _, err := cl.TransactionByHash(context.Background(), creation.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get transaction %s: %v", creation.TxHash, err)
+	}
+	receipt, err := cl.TransactionReceipt(context.Background(), creation.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get receipt for %s: %v", creation.TxHash, err)
+	}
+
+	report := &types.DeploymentReport{TxHash: creation.TxHash, FromFactory: tx.To() != nil}
+	if !report.FromFactory {
+		runtime, err := cl.CodeAt(context.Background(), tokenAddress, nil)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get code:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+		}
+
+		var abiJSON, published string
+		if source != nil && source.Verified && source.Address == tokenAddress {
+			abiJSON, published = source.ABI, source.ConstructorArguments
+		}
+		initCode, args, ok := contracts.SplitConstructorArgs(tx.Data(), runtime, published)
+		report.InitCodeSize = len(initCode)
+		if ok && len(args) > 0 {
+			report.Args, report.ArgsDecoded, err = contracts.DecodeConstructorArgs(args, abiJSON)
+			if err != nil {
+				// A mismatched ABI still leaves the raw words worth showing
+				report.Args, report.ArgsDecoded, _ = contracts.DecodeConstructorArgs(args, "")
+			}
+			for i := range report.Args {
+				report.Args[i].Note = describeArgAddress(report.Args[i], creation.Creator, addressLabels)
+			}
+		}
+	}
+
+	net := make(map[common.Address]*big.Int)
+	minted := new(big.Int)
+	for _, log := range receipt.Logs {
+		if log.Address != tokenAddress || len(log.Topics) != 3 || log.Topics[0] != transferEventID || len(log.Data) != 32 {
+			continue
+		}
+		from := common.BytesToAddress(log.Topics[1].Bytes())
+		to := common.BytesToAddress(log.Topics[2].Bytes())
+		value := new(big.Int).SetBytes(log.Data)
+		if from == (common.Address{}) {
+			minted.Add(minted, value)
+		} else {
+			if net[from] == nil {
+				net[from] = new(big.Int)
+			}
+			net[from].Sub(net[from], value)
+		}
+		if net[to] == nil {
+			net[to] = new(big.Int)
+		}
+		net[to].Add(net[to], value)
+	}
+	report.Minted = utils.ToDecimal(minted, decimals)
+
+	received := new(big.Int)
+	for _, amount := range net {
+		if amount.Sign() > 0 {
+			received.Add(received, amount)
+		}
+	}
+	for address, amount := range net {
+		if amount.Sign() <= 0 {
+			continue
+		}
+		allocation := types.InitialAllocation{
+			Address: address,
+			Amount:  utils.ToDecimal(amount, decimals),
+			Share:   sharePct(amount, received),
+		}
+		allocation.Kind, err = allocationKind(cl, address, tokenAddress, creation.Creator)
+		if err != nil {
+			return nil, err
+		}
+		if label, ok := addressLabels.Lookup(address); ok {
+			allocation.Label = label.Name
+		}
+		report.Distribution = append(report.Distribution, allocation)
+	}
+	sort.Slice(report.Distribution, func(i, j int) bool {
+		a, b := report.Distribution[i], report.Distribution[j]
+		if a.Share != b.Share {
+			return a.Share > b.Share
+		}
+		return a.Address.Hex() < b.Address.Hex()
+	})
+	return report, nil
+}
+
+func allocationKind(cl *ethclient.Client, address, tokenAddress, deployer common.Address) (string, error) {
+	switch {
+	case address == deployer:
+		return AllocationDeployer, nil
+	case address == tokenAddress:
+		return AllocationToken, nil
+	case address == (common.Address{}) || address == utils.DeadAddress:
+		return AllocationBurn, nil
+	}
+	code, err := cl.CodeAt(context.Background(), address, nil)
+	if err != nil {
+		return "", fmt.Errorf("\nFailed to get code at %s: %v", address, err)
+	}
+	if len(code) > 0 {
+		return AllocationContract, nil
+	}
+	return AllocationEOA, nil
+}
+
+// describeArgAddress names the addresses a constructor was given that are recognizable
+func describeArgAddress(arg types.ConstructorArg, deployer common.Address, addressLabels labels.Labels) string {
+	if arg.Type != "address" || !common.IsHexAddress(arg.Value) {
+		return ""
+	}
+	address := common.HexToAddress(arg.Value)
+	switch address {
+	case utils.UniswapRouterAddress:
+		return "Uniswap V2 router"
+	case utils.UniswapFactoryAddress:
+		return "Uniswap V2 factory"
+	case utils.WETHAddress:
+		return "WETH"
+	case deployer:
+		return "deployer"
+	}
+	if label, ok := addressLabels.Lookup(address); ok {
+		return label.Name
+	}
+	return ""
+}
+
+func printDeploymentReport(report *types.DeploymentReport, indent string) {
+	if report.FromFactory {
+		fmt.Printf("%sdeployed by a factory, no constructor arguments in the transaction\n", indent)
+	}
+	if len(report.Args) > 0 {
+		source := "undecoded words"
+		if report.ArgsDecoded {
+			source = "decoded with the verified ABI"
+		}
+		fmt.Printf("%sConstructor arguments (%s):\n", indent, source)
+		for _, arg := range report.Args {
+			line := fmt.Sprintf("%s  %-20s %-10s %s", indent, arg.Name, arg.Type, arg.Value)
+			if arg.Note != "" {
+				line += " (" + arg.Note + ")"
+			}
+			fmt.Println(line)
+		}
+	}
+	fmt.Printf("%sMinted at deployment: %s\n", indent, report.Minted.Text('f', 2))
+	for _, allocation := range report.Distribution {
+		label := allocation.Kind
+		if allocation.Label != "" {
+			label += ", " + allocation.Label
+		}
+		fmt.Printf("%s  %s %7.2f%%  %s\n", indent, allocation.Address, allocation.Share, label)
+	}
+}
@@ -0,0 +1,229 @@
+package dexes
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+type mintEvent struct {
+	Sender  common.Address
+	Amount0 *big.Int
+	Amount1 *big.Int
+}
+
+type burnEvent struct {
+	Sender  common.Address
+	Amount0 *big.Int
+	Amount1 *big.Int
+	To      common.Address
+}
+
+type transferEvent struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
+
+/*
+	Mint and Burn only name the router as sender, so the liquidity provider is taken
+	from the LP token Transfers in the same transaction:
+
+		- Add:    the pair mints LP tokens to the provider right before Mint
+		- Remove: the provider sends LP tokens to the pair, which burns them before Burn
+
+	Logs arrive in log order, so each Mint or Burn takes the Transfers seen since the
+	previous Mint or Burn of the same transaction. That keeps several adds or removes
+	batched in one transaction apart. The MINIMUM_LIQUIDITY minted to the zero address on
+	the first add is ignored.
+*/
+
+// pendingTransfers are the LP Transfers of a transaction not yet matched to a Mint or Burn
+type pendingTransfers struct {
+	minted   *transferEvent
+	burned   *big.Int
+	returned *common.Address
+}
+
+func GetLiquidityEvents(cl *ethclient.Client, pair *types.UniswapPair, fromBlock, toBlock uint64) ([]*types.LiquidityEvent, error) {
+	var eventIDs []common.Hash
+	for _, name := range []string{"Mint", "Burn", "Transfer"} {
+		id, err := GetPairEventID(name)
+		if err != nil {
+			return nil, err
+		}
+		eventIDs = append(eventIDs, id)
+	}
+	mintID, burnID, transferID := eventIDs[0], eventIDs[1], eventIDs[2]
+
+	logs, err := utils.FilterLogs(cl, []common.Address{pair.Address}, [][]common.Hash{eventIDs}, fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get liquidity logs:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	pending := make(map[common.Hash]*pendingTransfers)
+
+	var events []*types.LiquidityEvent
+	for _, log := range logs {
+		switch log.Topics[0] {
+		case transferID:
+			var transfer transferEvent
+			err := pair.Contract.UnpackLog(&transfer, "Transfer", log)
+			if err != nil {
+				return nil, fmt.Errorf("\nFailed to unpack Transfer log:\n\tTx Hash: %s\n\tError: %v", log.TxHash, err)
+			}
+			p := pending[log.TxHash]
+			if p == nil {
+				p = &pendingTransfers{}
+				pending[log.TxHash] = p
+			}
+			switch {
+			case transfer.From == (common.Address{}) && transfer.To != (common.Address{}):
+				p.minted = &transfer
+			case transfer.From == pair.Address && transfer.To == (common.Address{}):
+				p.burned = transfer.Value
+			case transfer.To == pair.Address && transfer.From != (common.Address{}):
+				p.returned = &transfer.From
+			}
+
+		case mintID:
+			var mint mintEvent
+			err := pair.Contract.UnpackLog(&mint, "Mint", log)
+			if err != nil {
+				return nil, fmt.Errorf("\nFailed to unpack Mint log:\n\tTx Hash: %s\n\tError: %v", log.TxHash, err)
+			}
+			event, err := newLiquidityEvent(cl, pair, log.BlockNumber, log.TxHash, mint.Amount0, mint.Amount1, blockTimes)
+			if err != nil {
+				return nil, err
+			}
+			event.Add = true
+			if p := pending[log.TxHash]; p != nil && p.minted != nil {
+				event.Provider = p.minted.To
+				event.LPAmount = p.minted.Value
+			}
+			delete(pending, log.TxHash)
+			events = append(events, event)
+
+		case burnID:
+			var burn burnEvent
+			err := pair.Contract.UnpackLog(&burn, "Burn", log)
+			if err != nil {
+				return nil, fmt.Errorf("\nFailed to unpack Burn log:\n\tTx Hash: %s\n\tError: %v", log.TxHash, err)
+			}
+			event, err := newLiquidityEvent(cl, pair, log.BlockNumber, log.TxHash, burn.Amount0, burn.Amount1, blockTimes)
+			if err != nil {
+				return nil, err
+			}
+			event.Provider = burn.To
+			if p := pending[log.TxHash]; p != nil {
+				if p.returned != nil {
+					event.Provider = *p.returned
+				}
+				if p.burned != nil {
+					event.LPAmount = p.burned
+				}
+			}
+			delete(pending, log.TxHash)
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func newLiquidityEvent(cl *ethclient.Client, pair *types.UniswapPair, blockNumber uint64, txHash common.Hash, amount0, amount1 *big.Int, blockTimes map[uint64]time.Time) (*types.LiquidityEvent, error) {
+	timestamp, err := utils.GetBlockTime(cl, blockNumber, blockTimes)
+	if err != nil {
+		return nil, err
+	}
+	tokenAmount, wethAmount := amount0, amount1
+	if !pair.TokenIsToken0 {
+		tokenAmount, wethAmount = amount1, amount0
+	}
+	return &types.LiquidityEvent{
+		BlockNumber: blockNumber,
+		Timestamp:   timestamp,
+		TxHash:      txHash,
+		TokenAmount: utils.ToDecimal(tokenAmount, pair.TokenDecimals),
+		WETHAmount:  utils.ToDecimal(wethAmount, 18),
+		LPAmount:    new(big.Int),
+	}, nil
+}
+
+// GetLPBalances replays the pair's LP token Transfers. LP tokens the pair burns on
+// removal are destroyed, while LP sent to the zero address by a holder stays as its balance.
+func GetLPBalances(cl *ethclient.Client, pair *types.UniswapPair, fromBlock, toBlock uint64) (map[common.Address]*big.Int, error) {
+	transferID, err := GetPairEventID("Transfer")
+	if err != nil {
+		return nil, err
+	}
+	logs, err := utils.FilterLogs(cl, []common.Address{pair.Address}, [][]common.Hash{{transferID}}, fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get LP Transfer logs:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+
+	balances := make(map[common.Address]*big.Int)
+	credit := func(address common.Address, value *big.Int) {
+		if balances[address] == nil {
+			balances[address] = new(big.Int)
+		}
+		balances[address].Add(balances[address], value)
+	}
+	for _, log := range logs {
+		var transfer transferEvent
+		err := pair.Contract.UnpackLog(&transfer, "Transfer", log)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to unpack Transfer log:\n\tTx Hash: %s\n\tError: %v", log.TxHash, err)
+		}
+		if transfer.From != (common.Address{}) {
+			credit(transfer.From, new(big.Int).Neg(transfer.Value))
+		}
+		if transfer.From == pair.Address && transfer.To == (common.Address{}) {
+			continue
+		}
+		credit(transfer.To, transfer.Value)
+	}
+
+	return balances, nil
+}
+
+// GetLPTransfers lists the pair's LP token Transfers with their block times
+func GetLPTransfers(cl *ethclient.Client, pair *types.UniswapPair, fromBlock, toBlock uint64) ([]*types.LPTransfer, error) {
+	transferID, err := GetPairEventID("Transfer")
+	if err != nil {
+		return nil, err
+	}
+	logs, err := utils.FilterLogs(cl, []common.Address{pair.Address}, [][]common.Hash{{transferID}}, fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get LP Transfer logs:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	var transfers []*types.LPTransfer
+	for _, log := range logs {
+		var transfer transferEvent
+		err := pair.Contract.UnpackLog(&transfer, "Transfer", log)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to unpack Transfer log:\n\tTx Hash: %s\n\tError: %v", log.TxHash, err)
+		}
+		timestamp, err := utils.GetBlockTime(cl, log.BlockNumber, blockTimes)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, &types.LPTransfer{
+			BlockNumber: log.BlockNumber,
+			Timestamp:   timestamp,
+			TxHash:      log.TxHash,
+			From:        transfer.From,
+			To:          transfer.To,
+			Value:       transfer.Value,
+		})
+	}
+	return transfers, nil
+}
@@ -0,0 +1,100 @@
+package dexes
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+type swapEvent struct {
+	Sender     common.Address
+	Amount0In  *big.Int
+	Amount1In  *big.Int
+	Amount0Out *big.Int
+	Amount1Out *big.Int
+	To         common.Address
+}
+
+func GetPairEventID(name string) (common.Hash, error) {
+	parsedABI, err := abi.JSON(strings.NewReader(utils.UniswapV2PairABI))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("\nFailed to parse UniswapV2PairABI: %v", err)
+	}
+	event, ok := parsedABI.Events[name]
+	if !ok {
+		return common.Hash{}, fmt.Errorf("\nUniswapV2PairABI has no %s event", name)
+	}
+	return event.ID, nil
+}
+
+func GetUniswapSwaps(cl *ethclient.Client, pair *types.UniswapPair, fromBlock, toBlock uint64) ([]*types.Swap, error) {
+	swapID, err := GetPairEventID("Swap")
+	if err != nil {
+		return nil, err
+	}
+	logs, err := utils.FilterLogs(cl, []common.Address{pair.Address}, [][]common.Hash{{swapID}}, fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get Swap logs:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	var swaps []*types.Swap
+	for _, log := range logs {
+		timestamp, err := utils.GetBlockTime(cl, log.BlockNumber, blockTimes)
+		if err != nil {
+			return nil, err
+		}
+		swap, err := DecodeUniswapSwap(pair, log, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		swaps = append(swaps, swap)
+	}
+
+	return swaps, nil
+}
+
+func DecodeUniswapSwap(pair *types.UniswapPair, log gethtypes.Log, timestamp time.Time) (*types.Swap, error) {
+	var event swapEvent
+	err := pair.Contract.UnpackLog(&event, "Swap", log)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to unpack Swap log:\n\tTx Hash: %s\n\tError: %v", log.TxHash, err)
+	}
+
+	tokenIn, tokenOut, wethIn, wethOut := event.Amount0In, event.Amount0Out, event.Amount1In, event.Amount1Out
+	if !pair.TokenIsToken0 {
+		tokenIn, tokenOut, wethIn, wethOut = event.Amount1In, event.Amount1Out, event.Amount0In, event.Amount0Out
+	}
+
+	// A buy takes tokens out of the pair, a sell puts them in
+	buy := tokenOut.Sign() > 0
+	tokenAmount, wethAmount := tokenIn, wethOut
+	if buy {
+		tokenAmount, wethAmount = tokenOut, wethIn
+	}
+
+	swap := &types.Swap{
+		BlockNumber: log.BlockNumber,
+		Timestamp:   timestamp,
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+		Sender:      event.Sender,
+		Recipient:   event.To,
+		Buy:         buy,
+		TokenAmount: utils.ToDecimal(tokenAmount, pair.TokenDecimals),
+		WETHAmount:  utils.ToDecimal(wethAmount, 18),
+	}
+	if swap.TokenAmount.Sign() > 0 {
+		swap.PriceInWETH = new(big.Float).Quo(swap.WETHAmount, swap.TokenAmount)
+	}
+
+	return swap, nil
+}
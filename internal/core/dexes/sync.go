@@ -0,0 +1,73 @@
+package dexes
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+type syncEvent struct {
+	Reserve0 *big.Int
+	Reserve1 *big.Int
+}
+
+func DecodeUniswapSync(pair *types.UniswapPair, log gethtypes.Log, timestamp time.Time) (*types.Reserves, error) {
+	var event syncEvent
+	err := pair.Contract.UnpackLog(&event, "Sync", log)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to unpack Sync log:\n\tTx Hash: %s\n\tError: %v", log.TxHash, err)
+	}
+
+	tokenReserve, wethReserve := event.Reserve0, event.Reserve1
+	if !pair.TokenIsToken0 {
+		tokenReserve, wethReserve = event.Reserve1, event.Reserve0
+	}
+
+	reserves := &types.Reserves{
+		BlockNumber:  log.BlockNumber,
+		Timestamp:    timestamp,
+		TxHash:       log.TxHash,
+		LogIndex:     log.Index,
+		TokenReserve: utils.ToDecimal(tokenReserve, pair.TokenDecimals),
+		WETHReserve:  utils.ToDecimal(wethReserve, 18),
+	}
+	if reserves.TokenReserve.Sign() > 0 {
+		reserves.PriceInWETH = new(big.Float).Quo(reserves.WETHReserve, reserves.TokenReserve)
+	}
+
+	return reserves, nil
+}
+
+// GetReserveHistory replays the pair's Sync events, giving its reserves after every change
+func GetReserveHistory(cl *ethclient.Client, pair *types.UniswapPair, fromBlock, toBlock uint64) ([]*types.Reserves, error) {
+	syncID, err := GetPairEventID("Sync")
+	if err != nil {
+		return nil, err
+	}
+	logs, err := utils.FilterLogs(cl, []common.Address{pair.Address}, [][]common.Hash{{syncID}}, fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get Sync logs:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	var history []*types.Reserves
+	for _, log := range logs {
+		timestamp, err := utils.GetBlockTime(cl, log.BlockNumber, blockTimes)
+		if err != nil {
+			return nil, err
+		}
+		reserves, err := DecodeUniswapSync(pair, log, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, reserves)
+	}
+
+	return history, nil
+}
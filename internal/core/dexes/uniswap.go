@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/types"
 	"github.com/zachmdsi/go-token-cli/internal/utils"
 )
 
@@ -26,7 +27,7 @@ func GetUniswapData(cl *ethclient.Client, tokenAddress common.Address) (*big.Flo
 	}
 	priceInWETH, err := GetTokenPriceInWETH(cl, pair, tokenAddress)	
 	if err != nil {
-		return nil, "", fmt.Errorf("\nNewUniswapV2Factory() failed: %v", err)
+		return nil, "", fmt.Errorf("\nGetTokenPriceInWETH() failed: %v", err)
 	}
 	return priceInWETH, "", nil
 }
@@ -41,15 +42,10 @@ func NewUniswapV2Factory(cl *ethclient.Client) (*bind.BoundContract, error) {
 }
 
 func NewUniswapPairContract(cl *ethclient.Client, factory*bind.BoundContract, tokenAddress common.Address) (*bind.BoundContract, error){
-	var pairAddress common.Address
-	var factoryCallResult []interface{}
-	err := factory.Call(&bind.CallOpts{}, &factoryCallResult, "getPair", tokenAddress, utils.WETHAddress)
+	pairAddress, err := GetUniswapPairAddress(factory, tokenAddress)
 	if err != nil {
 		return nil, err
-	}
-	if len(factoryCallResult) > 0 {
-		pairAddress = factoryCallResult[0].(common.Address)
-	} else {
+	} else if pairAddress == (common.Address{}) {
 		return nil, nil
 	}
 
@@ -63,6 +59,92 @@ func NewUniswapPairContract(cl *ethclient.Client, factory*bind.BoundContract, to
 	return pair, nil
 }
 
+func GetUniswapPairAddress(factory *bind.BoundContract, tokenAddress common.Address) (common.Address, error) {
+	var factoryCallResult []interface{}
+	err := factory.Call(&bind.CallOpts{}, &factoryCallResult, "getPair", tokenAddress, utils.WETHAddress)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if len(factoryCallResult) == 0 {
+		return common.Address{}, nil
+	}
+	return factoryCallResult[0].(common.Address), nil
+}
+
+// GetUniswapPair looks up the token's WETH pair and binds it together with the
+// token ordering and decimals needed to normalize pair amounts. A nil pair is
+// returned when the token has no Uniswap V2 WETH pair.
+func GetUniswapPair(cl *ethclient.Client, tokenAddress common.Address) (*types.UniswapPair, error) {
+	factory, err := NewUniswapV2Factory(cl)
+	if err != nil {
+		return nil, fmt.Errorf("\nNewUniswapV2Factory() failed: %v", err)
+	}
+	pairAddress, err := GetUniswapPairAddress(factory, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetUniswapPairAddress() failed: %v", err)
+	} else if pairAddress == (common.Address{}) {
+		return nil, nil
+	}
+	pair, err := NewUniswapPairContract(cl, factory, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nNewUniswapPairContract() failed: %v", err)
+	}
+
+	var token0Result []interface{}
+	err = pair.Call(&bind.CallOpts{}, &token0Result, "token0")
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to call token0(): %v", err)
+	}
+
+	tokenDecimals, err := utils.GetTokenDecimals(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetTokenDecimals() failed: %v", err)
+	}
+
+	return &types.UniswapPair{
+		Address:       pairAddress,
+		Contract:      pair,
+		Token:         tokenAddress,
+		TokenIsToken0: token0Result[0].(common.Address) == tokenAddress,
+		TokenDecimals: tokenDecimals,
+	}, nil
+}
+
+// GetETHPriceInUSD prices WETH from the reserves of the Uniswap V2 USDC/WETH pair
+func GetETHPriceInUSD(cl *ethclient.Client) (*big.Float, error) {
+	factory, err := NewUniswapV2Factory(cl)
+	if err != nil {
+		return nil, fmt.Errorf("\nNewUniswapV2Factory() failed: %v", err)
+	}
+	pair, err := NewUniswapPairContract(cl, factory, utils.USDCAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nNewUniswapPairContract() failed: %v", err)
+	} else if pair == nil {
+		return nil, fmt.Errorf("\nNo Uniswap V2 USDC/WETH pair found")
+	}
+
+	var pairCallResult []interface{}
+	err = pair.Call(&bind.CallOpts{}, &pairCallResult, "getReserves")
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to call getReserves(): %v", err)
+	}
+	var token0Result []interface{}
+	err = pair.Call(&bind.CallOpts{}, &token0Result, "token0")
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to call token0(): %v", err)
+	}
+
+	usdcReserve, wethReserve := pairCallResult[0].(*big.Int), pairCallResult[1].(*big.Int)
+	if token0Result[0].(common.Address) != utils.USDCAddress {
+		usdcReserve, wethReserve = wethReserve, usdcReserve
+	}
+	if wethReserve.Sign() == 0 {
+		return nil, fmt.Errorf("\nUniswap V2 USDC/WETH pair has no WETH reserve")
+	}
+
+	return new(big.Float).Quo(utils.ToDecimal(usdcReserve, 6), utils.ToDecimal(wethReserve, 18)), nil
+}
+
 func GetTokenPriceInWETH(cl *ethclient.Client, pair *bind.BoundContract, tokenAddress common.Address) (*big.Float, error) {
 	var reserves [2]*big.Int
 	var pairCallResult []interface{}
@@ -81,6 +163,9 @@ func GetTokenPriceInWETH(cl *ethclient.Client, pair *bind.BoundContract, tokenAd
 	if err != nil {
 		return nil, err
 	}
+	if len(pairCallResultToken0) > 0 {
+		token0Address = pairCallResultToken0[0].(common.Address)
+	}
 
 	var tokenReserve, wethReserve *big.Int
 	if token0Address == tokenAddress {
@@ -89,7 +174,7 @@ func GetTokenPriceInWETH(cl *ethclient.Client, pair *bind.BoundContract, tokenAd
 		tokenReserve, wethReserve = reserves[1], reserves[0]
 	}
 
-	if wethReserve.Cmp(big.NewInt(0)) == 0 {
+	if wethReserve == nil || wethReserve.Cmp(big.NewInt(0)) == 0 || tokenReserve.Cmp(big.NewInt(0)) == 0 {
 		return nil, nil
 	}
 
@@ -102,7 +187,9 @@ func GetTokenPriceInWETH(cl *ethclient.Client, pair *bind.BoundContract, tokenAd
 
 	normalizedTokenReserve := new(big.Float).Quo(new(big.Float).SetInt(tokenReserve), tokenDecimalsFactor)
 
-	price := new(big.Float).Quo(normalizedTokenReserve, new(big.Float).SetInt(wethReserve))
+	normalizedWETHReserve := utils.ToDecimal(wethReserve, 18)
+
+	price := new(big.Float).Quo(normalizedWETHReserve, normalizedTokenReserve)
 
 	minPrice := 0.000000000000000001
 	maxPrice := 1000.0
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	An address's first funding is found by binary searching for the first block where it
+	has a balance or has sent a transaction (one can't happen without the other for an
+	EOA, which keeps the search monotonic). That block is then searched for:
+
+		1. a transaction sending ETH directly to the address
+		2. a transaction whose logs mention the address, for ETH sent from inside a contract
+		   call such as a mixer withdrawal or a bridge release. The called contract is taken
+		   as the funder.
+		3. the block's coinbase and withdrawals
+
+	Tracing repeats from the funder until it is labelled, is a contract, or maxHops is
+	reached. Reading balances at historical blocks requires an archive node.
+*/
+
+func TraceFunding(cl *ethclient.Client, address common.Address, beforeBlock uint64, maxHops int, addressLabels labels.Labels) (*types.FundingTrace, error) {
+	trace := &types.FundingTrace{}
+	current, block := address, beforeBlock
+	for hop := 0; hop < maxHops; hop++ {
+		funding, err := findFirstFunding(cl, current, block)
+		if err != nil {
+			return nil, fmt.Errorf("\nfindFirstFunding() failed:\n\tAddress: %s\n\tError: %v", current, err)
+		} else if funding == nil {
+			break
+		}
+
+		if label, ok := addressLabels.Lookup(funding.Funder); ok {
+			funding.FunderLabel, funding.FunderCategory = label.Name, label.Category
+		}
+		if funding.Funder != (common.Address{}) {
+			code, err := cl.CodeAt(context.Background(), funding.Funder, nil)
+			if err != nil {
+				return nil, fmt.Errorf("\nFailed to get code at %s: %v", funding.Funder, err)
+			}
+			funding.FunderIsContract = len(code) > 0
+		}
+
+		trace.Hops = append(trace.Hops, funding)
+		trace.Source, trace.SourceLabel, trace.SourceCategory = funding.Funder, funding.FunderLabel, funding.FunderCategory
+		if funding.FunderLabel != "" || funding.FunderIsContract || funding.Funder == (common.Address{}) {
+			break
+		}
+		current, block = funding.Funder, funding.BlockNumber
+	}
+	return trace, nil
+}
+
+// findFirstFunding returns the first funding of address up to beforeBlock, or nil when
+// it had no ETH by then
+func findFirstFunding(cl *ethclient.Client, address common.Address, beforeBlock uint64) (*types.FundingHop, error) {
+	funded := func(blockNumber uint64) (bool, error) {
+		number := new(big.Int).SetUint64(blockNumber)
+		balance, err := cl.BalanceAt(context.Background(), address, number)
+		if err != nil {
+			return false, fmt.Errorf("\nFailed to get balance at block %d: %v", blockNumber, err)
+		}
+		if balance.Sign() > 0 {
+			return true, nil
+		}
+		nonce, err := cl.NonceAt(context.Background(), address, number)
+		if err != nil {
+			return false, fmt.Errorf("\nFailed to get nonce at block %d: %v", blockNumber, err)
+		}
+		return nonce > 0, nil
+	}
+	if ok, err := funded(beforeBlock); err != nil || !ok {
+		return nil, err
+	}
+	fundedBlock, err := utils.SearchBlocks(0, beforeBlock, funded)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := cl.BlockByNumber(context.Background(), new(big.Int).SetUint64(fundedBlock))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block %d: %v", fundedBlock, err)
+	}
+	balance, err := cl.BalanceAt(context.Background(), address, block.Number())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get balance at block %d: %v", fundedBlock, err)
+	}
+	funding := &types.FundingHop{
+		Address:     address,
+		BlockNumber: fundedBlock,
+		Timestamp:   time.Unix(int64(block.Time()), 0).UTC(),
+		Amount:      utils.ToDecimal(balance, 18),
+	}
+
+	for _, tx := range block.Transactions() {
+		if tx.To() == nil || *tx.To() != address || tx.Value().Sign() == 0 {
+			continue
+		}
+		funding.Funder, err = gethtypes.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get the signer: %v", err)
+		}
+		funding.TxHash = tx.Hash()
+		funding.Amount = utils.ToDecimal(tx.Value(), 18)
+		return funding, nil
+	}
+
+	padded := common.LeftPadBytes(address.Bytes(), 32)
+	for _, tx := range block.Transactions() {
+		if tx.To() == nil {
+			continue
+		}
+		receipt, err := cl.TransactionReceipt(context.Background(), tx.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get receipt for %s: %v", tx.Hash(), err)
+		}
+		for _, log := range receipt.Logs {
+			mentioned := bytes.Contains(log.Data, padded)
+			for _, topic := range log.Topics {
+				mentioned = mentioned || topic == common.BytesToHash(padded)
+			}
+			if mentioned {
+				funding.Funder = *tx.To()
+				funding.TxHash = tx.Hash()
+				funding.Internal = true
+				return funding, nil
+			}
+		}
+	}
+
+	// Left unattributed, the funder stays the zero address
+	if block.Coinbase() == address {
+		funding.FunderLabel = "block reward"
+	}
+	for _, withdrawal := range block.Withdrawals() {
+		if withdrawal.Address == address {
+			funding.FunderLabel = "beacon chain withdrawal"
+		}
+	}
+	return funding, nil
+}
+
+func printFundingTrace(trace *types.FundingTrace, indent string) {
+	for _, hop := range trace.Hops {
+		funder := hop.Funder.Hex()
+		if hop.FunderLabel != "" {
+			funder += " (" + hop.FunderLabel + ")"
+		} else if hop.Funder == (common.Address{}) {
+			funder = "unknown"
+		}
+		via := ""
+		if hop.Internal {
+			via = " via contract call"
+		}
+		fmt.Printf("%s%s <- %s  %s ETH at block %d%s\n", indent, hop.Address.Hex(), funder, hop.Amount.Text('f', 4), hop.BlockNumber, via)
+	}
+}
+
+// describeFundingSource summarizes where a trace ended
+func describeFundingSource(trace *types.FundingTrace) string {
+	if len(trace.Hops) == 0 {
+		return "no funding found"
+	}
+	last := trace.Hops[len(trace.Hops)-1]
+	switch {
+	case trace.SourceLabel != "" && trace.SourceCategory != "":
+		return fmt.Sprintf("%s [%s] after %d hops", trace.SourceLabel, trace.SourceCategory, len(trace.Hops))
+	case trace.SourceLabel != "":
+		return fmt.Sprintf("%s after %d hops", trace.SourceLabel, len(trace.Hops))
+	case last.Funder == (common.Address{}):
+		return fmt.Sprintf("unknown after %d hops", len(trace.Hops))
+	case last.FunderIsContract:
+		return fmt.Sprintf("unlabelled contract %s after %d hops", trace.Source, len(trace.Hops))
+	default:
+		return fmt.Sprintf("%s after %d hops (hop limit)", trace.Source, len(trace.Hops))
+	}
+}
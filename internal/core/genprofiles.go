@@ -1,13 +1,25 @@
 package core
 
 import (
+	"context"
 	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/config"
 	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
 	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/core/simulation"
+	"github.com/zachmdsi/go-token-cli/internal/etherscan"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/store"
 	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
 )
 
 /*
@@ -59,17 +71,93 @@ import (
 
 */
 
-func GenerateTokenProfiles(ethNodeURL string, numBlock uint64, erc20addresses []string) ([]*types.Token, error) {
-	fmt.Println("\nGenerating token profiles")
+// simulationFunding is the ETH balance given to the throwaway address that runs trade simulations
+var simulationFunding = new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18))
 
-	cl, err := ethclient.Dial(ethNodeURL)
+type ProfileOptions struct {
+	// LocalSimulation runs trade simulations in the in-process EVM instead of
+	// relying on the node's eth_call state overrides
+	LocalSimulation bool
+	// MaxTop10Share drops tokens whose 10 largest holders hold a larger percentage of
+	// the held supply, zero keeps every token
+	MaxTop10Share float64
+	// FundingHops limits how far back each deployer's funding is traced
+	FundingHops int
+	// SniperBlocks is how many blocks after launch are checked for snipers
+	SniperBlocks uint64
+	// MaxRisk drops tokens scoring above it, 100 keeps every token
+	MaxRisk float64
+	// SortBy orders the profiles, either "risk" (highest first) or empty for discovery order
+	SortBy string
+}
+
+func GenerateTokenProfiles(conf config.Config, numBlock uint64, erc20Creations []*types.ContractCreation, opts ProfileOptions) ([]*types.Token, error) {
+	fmt.Fprintln(os.Stderr, "\nGenerating token profiles")
+
+	cl, err := ethclient.Dial(conf.EthNodeURL)
 	if err != nil {
 		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
 	}
 
+	st, err := store.Open(conf.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("\nstore.Open() failed: %v", err)
+	}
+	addressLabels, err := labels.Load(conf.LabelsFile)
+	if err != nil {
+		return nil, fmt.Errorf("\nlabels.Load() failed: %v", err)
+	}
+	selectorDB, err := contracts.LoadSelectorDB(conf.SelectorsFile)
+	if err != nil {
+		return nil, fmt.Errorf("\nLoadSelectorDB() failed: %v", err)
+	}
+
+	// The explorer is only used when one is configured, the on-chain lookups cover the rest
+	var explorer *etherscan.Client
+	if conf.EtherscanAPIKey != "" || conf.EtherscanBaseURL != "" {
+		explorer = etherscan.NewClient(conf.EtherscanBaseURL, conf.EtherscanAPIKey, conf.EtherscanChainID, conf.EtherscanRateLimit, st)
+	}
+
+	cloneDB, err := OpenCloneDB(st)
+	if err != nil {
+		return nil, fmt.Errorf("\nOpenCloneDB() failed: %v", err)
+	}
+
+	simulationAddress, err := simulation.NewThrowawayAddress()
+	if err != nil {
+		return nil, fmt.Errorf("\nNewThrowawayAddress() failed: %v", err)
+	}
+	// Transfer restriction tests need persistent state, so the simulator is always used
+	// for them even when the round trips go through the node
+	simulator, err := simulation.NewSimulator(conf.EthNodeURL, nil, conf.SimulationCacheDir)
+	if err != nil {
+		return nil, fmt.Errorf("\nNewSimulator() failed: %v", err)
+	}
+	defer func() {
+		// A lost cache only costs refetching state next run, so it doesn't fail the profiles
+		if err := simulator.SaveCache(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save simulation cache: %v\n", err)
+		}
+	}()
+
+	var executor simulation.Executor
+	if opts.LocalSimulation {
+		executor = simulation.NewLocalExecutor(simulator, simulationAddress, simulationFunding)
+	} else {
+		executor, err = simulation.NewRPCExecutor(conf.EthNodeURL, simulationAddress, simulationFunding, nil)
+		if err != nil {
+			return nil, fmt.Errorf("\nNewRPCExecutor() failed: %v", err)
+		}
+	}
+
+	ethPriceInUSD, err := dexes.GetETHPriceInUSD(cl)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetETHPriceInUSD() failed: %v", err)
+	}
+
 	var tokens []*types.Token
-	for _, address := range erc20addresses {
-		tokenAddress := common.HexToAddress(address)
+	for _, creation := range erc20Creations {
+		tokenAddress := creation.Contract
 		tokenContractData, err := contracts.GetBasicContractData(cl, tokenAddress)
 		if err != nil {
 			return nil, fmt.Errorf("\nGetBasicContractData() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
@@ -86,20 +174,415 @@ func GenerateTokenProfiles(ethNodeURL string, numBlock uint64, erc20addresses []
 					TotalSupply: tokenContractData.TotalSupply,
 					UniswapPriceInWETH: tokenUniswapPriceInWETH,
 				}
+
+				// Deployments found by address only still need their transaction looked up.
+				// That needs archive state or the explorer, so a failure only skips the token.
+				if creation.TxHash == (common.Hash{}) {
+					creation, err = getContractCreation(cl, explorer, tokenAddress)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Skipping %s, deployment not found: %v\n", tokenAddress, err)
+						continue
+					}
+				}
+				newToken.ContractCreator = creation.Creator
+				newToken.ContractCreationDate = creation.Timestamp
+				if creation.BlockNumber > 0 {
+					newToken.Deployer, err = GetDeployerReport(cl, creation.Creator, creation.BlockNumber-1)
+					if err != nil {
+						return nil, fmt.Errorf("\nGetDeployerReport() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+					}
+					newToken.Deployer.Funding, err = TraceFunding(cl, creation.Creator, creation.BlockNumber, opts.FundingHops, addressLabels)
+					if err != nil {
+						return nil, fmt.Errorf("\nTraceFunding() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+					}
+				}
+
+				newToken.Proxy, err = contracts.GetProxyInfo(cl, tokenAddress)
+				if err != nil {
+					return nil, fmt.Errorf("\nGetProxyInfo() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+				// A proxy's privileged functions live in its implementation
+				logicAddress := tokenAddress
+				if newToken.Proxy != nil && newToken.Proxy.Implementation != (common.Address{}) {
+					logicAddress = newToken.Proxy.Implementation
+				}
+				newToken.Capabilities, err = contracts.ScanCapabilities(cl, logicAddress, selectorDB)
+				if err != nil {
+					return nil, fmt.Errorf("\nScanCapabilities() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+
+				fingerprint, err := contracts.FingerprintContract(cl, logicAddress)
+				if err != nil {
+					return nil, fmt.Errorf("\nFingerprintContract() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+				if fingerprint != nil {
+					newToken.CodeHash = fingerprint.Hash
+				}
+				newToken.Clone, err = cloneDB.Match(cl, fingerprint, tokenAddress)
+				if err != nil {
+					return nil, fmt.Errorf("\nMatch() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+				err = cloneDB.Record(fingerprint, types.CloneDeployment{
+					Token:       tokenAddress,
+					Name:        newToken.Name,
+					Symbol:      newToken.Symbol,
+					Deployer:    creation.Creator,
+					BlockNumber: creation.BlockNumber,
+				})
+				if err != nil {
+					return nil, fmt.Errorf("\nRecord() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+
+				if explorer != nil {
+					newToken.Source, err = explorer.GetSourceCode(logicAddress)
+					if err != nil {
+						return nil, fmt.Errorf("\nGetSourceCode() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+					}
+					newToken.SourceScan, err = contracts.ScanSource(newToken.Source)
+					if err != nil {
+						return nil, fmt.Errorf("\nScanSource() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+					}
+				}
+
+				newToken.Deployment, err = AnalyzeDeployment(cl, tokenAddress, creation, newToken.Source, newToken.Decimals, addressLabels)
+				if err != nil {
+					// A section that fails is left out, and ScoreRisk reports it as not available
+					fmt.Fprintf(os.Stderr, "Leaving deployment analysis out of the %s profile: %v\n", tokenAddress, err)
+				}
+
+				newToken.Ownership, err = contracts.GetOwnership(cl, tokenAddress, creation.BlockNumber)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Leaving ownership out of the %s profile: %v\n", tokenAddress, err)
+				}
+
+				holderIndex, err := GetHolderData(cl, st, newToken, creation.BlockNumber)
+				if err != nil {
+					return nil, fmt.Errorf("\nGetHolderData() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+
+				newToken.SupplyCheck, err = CheckSupplyConsistency(cl, holderIndex, creation.BlockNumber)
+				if err != nil {
+					return nil, fmt.Errorf("\nCheckSupplyConsistency() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+
+				err = GetSupplyData(cl, conf, newToken, ethPriceInUSD)
+				if err != nil {
+					return nil, fmt.Errorf("\nGetSupplyData() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+
+				pair, err := dexes.GetUniswapPair(cl, tokenAddress)
+				if err != nil {
+					return nil, fmt.Errorf("\nGetUniswapPair() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+				newToken.Concentration, err = AnalyzeConcentration(cl, holderIndex, pair)
+				if err != nil {
+					return nil, fmt.Errorf("\nAnalyzeConcentration() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+				if opts.MaxTop10Share > 0 && newToken.Concentration.Top10Pct > opts.MaxTop10Share {
+					continue
+				}
+
+				newToken.Clusters, err = ClusterHolders(cl, st, holderIndex, creation.BlockNumber, pair, addressLabels)
+				if err != nil {
+					return nil, fmt.Errorf("\nClusterHolders() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+
+				newToken.LPAnalysis, err = AnalyzeLPHolders(cl, pair, creation.Creator, conf.LPLockers)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Leaving LP holders out of the %s profile: %v\n", tokenAddress, err)
+				}
+
+				newToken.Snipers, err = AnalyzeSnipers(cl, pair, creation.Creator, opts.SniperBlocks, addressLabels)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Leaving snipers out of the %s profile: %v\n", tokenAddress, err)
+				}
+
+				buyAmount, err := simulation.GetBuyAmount(pair)
+				if err != nil {
+					return nil, fmt.Errorf("\nGetBuyAmount() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+				if buyAmount.Sign() > 0 {
+					newToken.Honeypot, err = simulation.SimulateRoundTrip(executor, pair, buyAmount)
+					if err != nil {
+						return nil, fmt.Errorf("\nSimulateRoundTrip() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+					}
+				}
+
+				newToken.TransferRestrictions, err = simulation.AnalyzeTransferRestrictions(simulator, tokenAddress, newToken.Decimals)
+				if err != nil {
+					return nil, fmt.Errorf("\nAnalyzeTransferRestrictions() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+
+				newToken.Timeline, err = BuildTimeline(cl, tokenAddress, creation, newToken.Ownership, pair, conf.LPLockers)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Leaving timeline out of the %s profile: %v\n", tokenAddress, err)
+				}
+
+				newToken.Risk = ScoreRisk(newToken, conf.RiskWeights)
+				if newToken.Risk.Score > opts.MaxRisk {
+					continue
+				}
+
+				// Profiled tokens are re-evaluated by the monitor from here on
+				err = TrackToken(cl, st, newToken)
+				if err != nil {
+					return nil, fmt.Errorf("\nTrackToken() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+				}
+
 				tokens = append(tokens, newToken)
 			}
 		}
 	}
 
-	for _, token := range tokens {
-		fmt.Printf("\nAddress:               %s\n", token.Address)
-		fmt.Printf("Name:                  %s\n", token.Name)
-		fmt.Printf("Symbol:                %s\n", token.Symbol)
-		fmt.Printf("Decimals:              %d\n", token.Decimals)
-		fmt.Printf("Total Supply:          %s\n", token.TotalSupply)
-		fmt.Printf("Uniswap Price in WETH: %.18f\n", token.UniswapPriceInWETH)
-		fmt.Println()
+	if opts.SortBy == "risk" {
+		sort.SliceStable(tokens, func(i, j int) bool {
+			return tokens[i].Risk.Score > tokens[j].Risk.Score
+		})
 	}
 
 	return tokens, nil
 }
+
+// getContractCreation asks the explorer for the deployment when one is configured, which
+// saves the creation block search, and fills in the block from the deployment receipt.
+// Contracts the explorer doesn't know are searched for on-chain.
+func getContractCreation(cl *ethclient.Client, explorer *etherscan.Client, tokenAddress common.Address) (*types.ContractCreation, error) {
+	if explorer == nil {
+		return contracts.GetContractCreator(cl, tokenAddress)
+	}
+	creation, err := explorer.GetContractCreation(tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetContractCreation() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	} else if creation == nil {
+		return contracts.GetContractCreator(cl, tokenAddress)
+	}
+	receipt, err := cl.TransactionReceipt(context.Background(), creation.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get receipt for %s: %v", creation.TxHash, err)
+	}
+	header, err := cl.HeaderByNumber(context.Background(), receipt.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block %s: %v", receipt.BlockNumber, err)
+	}
+	creation.BlockNumber = receipt.BlockNumber.Uint64()
+	creation.Timestamp = time.Unix(int64(header.Time), 0).UTC()
+	return creation, nil
+}
+
+func PrintTokenProfiles(tokens []*types.Token) {
+	for _, token := range tokens {
+		printTokenProfile(token)
+	}
+}
+
+func printTokenProfile(token *types.Token) {
+	fmt.Printf("\nAddress:               %s\n", token.Address)
+	if token.Risk != nil {
+		fmt.Printf("Risk Score:            %.0f/100\n", token.Risk.Score)
+		for _, factor := range token.Risk.Factors {
+			fmt.Printf("  %-22s %5.1f/%-5.0f %s\n", factor.Name, factor.Contribution, factor.Weight, factor.Evidence)
+		}
+	}
+	fmt.Printf("Name:                  %s\n", token.Name)
+	fmt.Printf("Symbol:                %s\n", token.Symbol)
+	fmt.Printf("Decimals:              %d\n", token.Decimals)
+	fmt.Printf("Total Supply:          %s\n", token.TotalSupply)
+	if token.CirculatingSupply != nil {
+		fmt.Printf("Circulating Supply:    %s\n", token.CirculatingSupply)
+		for _, exclusion := range token.SupplyExclusions {
+			fmt.Printf("  %s %7.2f%%  %s\n", exclusion.Address, exclusion.Share, exclusion.Label)
+		}
+	}
+	fmt.Printf("Creator:               %s\n", token.ContractCreator)
+	fmt.Printf("Created:               %s\n", token.ContractCreationDate.Format(time.RFC3339))
+	if d := token.Deployer; d != nil {
+		fmt.Printf("Deployer History:      %d contracts, %d tokens, %d rugged\n", len(d.Contracts), d.TokenCount, d.RuggedCount)
+		if d.FirstActivity != nil {
+			age := token.ContractCreationDate.Sub(*d.FirstActivity)
+			fmt.Printf("Deployer Age:          %.1f days at deployment\n", age.Hours()/24)
+		} else {
+			fmt.Println("Deployer Age:          no transactions before deployment")
+		}
+		fmt.Printf("Deployer Balance:      %s ETH at deployment\n", d.Balance.Text('f', 4))
+		if d.Funding != nil {
+			fmt.Printf("Deployer Funding:      %s\n", describeFundingSource(d.Funding))
+			printFundingTrace(d.Funding, "  ")
+		}
+	}
+	if p := token.Proxy; p != nil {
+		fmt.Printf("Proxy:                 %s -> %s (upgradeable: %t)\n", p.Kind, p.Implementation, p.Upgradeable)
+		if p.Admin != (common.Address{}) {
+			fmt.Printf("Proxy Admin:           %s\n", p.Admin)
+		}
+	}
+	if token.Deployment != nil {
+		fmt.Printf("Deployment:            %s\n", token.Deployment.TxHash)
+		printDeploymentReport(token.Deployment, "  ")
+	}
+	if token.Clone != nil {
+		fmt.Printf("Clone Of:              %s\n", describeCloneFamily(token.Clone))
+	} else if token.CodeHash != (common.Hash{}) {
+		fmt.Println("Clone Of:              no earlier deployments of this code")
+	}
+	if src := token.Source; src != nil {
+		if src.Verified {
+			fmt.Printf("Verified:              yes (%s, %s)\n", src.ContractName, src.CompilerVersion)
+		} else {
+			fmt.Println("Verified:              no")
+		}
+	}
+	if scan := token.SourceScan; scan != nil {
+		if len(scan.Findings) > 0 {
+			fmt.Println("Source Findings:")
+			for _, finding := range scan.Findings {
+				fmt.Printf("  %-22s %s:%d  %s\n", finding.Pattern, finding.File, finding.Line, finding.Detail)
+				fmt.Printf("  %-22s %s\n", "", finding.Snippet)
+			}
+		} else {
+			fmt.Printf("Source Findings:       none in %d files\n", scan.Files)
+		}
+		for _, link := range scan.Links {
+			fmt.Printf("Social Link:           %-9s %s (%s:%d)\n", link.Kind, link.URL, link.File, link.Line)
+		}
+	}
+	if len(token.Capabilities) > 0 {
+		fmt.Println("Capabilities:")
+		for _, capability := range token.Capabilities {
+			fmt.Printf("  %-15s %s %s\n", capability.Name, capability.Selector, capability.Signature)
+		}
+	} else {
+		fmt.Println("Capabilities:          none found")
+	}
+	if o := token.Ownership; o != nil {
+		switch {
+		case o.Source == "":
+			fmt.Println("Owner:                 none found")
+		case o.Renounced:
+			fmt.Printf("Owner:                 renounced (%s)\n", o.Owner)
+			if o.RenouncedAt != nil {
+				fmt.Printf("Renounced:             %s (block %d)\n", o.RenouncedAt.Timestamp.Format(time.RFC3339), o.RenouncedAt.BlockNumber)
+			}
+		default:
+			fmt.Printf("Owner:                 %s (%s, via %s)\n", o.Owner, o.OwnerKind, o.Source)
+			if o.OwnerKind == types.OwnerKindMultisig {
+				fmt.Printf("Multisig:              %d of %d\n", o.MultisigThreshold, o.MultisigOwners)
+			} else if o.OwnerKind == types.OwnerKindTimelock {
+				fmt.Printf("Timelock Delay:        %s\n", o.TimelockDelay)
+			}
+		}
+		fmt.Printf("Ownership Transfers:   %d\n", len(o.Transfers))
+	}
+	fmt.Printf("Uniswap Price in WETH: %.18f\n", token.UniswapPriceInWETH)
+	if c := token.Concentration; c != nil {
+		fmt.Printf("Top 10 Holders:        %.2f%%\n", c.Top10Pct)
+		fmt.Printf("Top 50 Holders:        %.2f%%\n", c.Top50Pct)
+		fmt.Printf("Gini Coefficient:      %.3f\n", c.Gini)
+		fmt.Printf("Nakamoto Coefficient:  %d\n", c.Nakamoto)
+		fmt.Printf("Held by Contracts:     %.2f%%\n", c.ContractPct)
+		fmt.Printf("Held by EOAs:          %.2f%%\n", c.EOAPct)
+	}
+	if sc := token.SupplyCheck; sc != nil {
+		flags := "none"
+		if len(sc.Flags) > 0 {
+			flags = strings.Join(sc.Flags, ", ")
+		}
+		fmt.Printf("Supply Check:          %s (%d samples, %d skipped)\n", flags, len(sc.Samples), sc.SamplesSkipped)
+		for _, sample := range sc.Samples {
+			if sample.Difference.Sign() != 0 {
+				fmt.Printf("  block %-10d totalSupply off by %s from events\n", sample.BlockNumber, utils.ToDecimal(sample.Difference, token.Decimals).Text('f', 2))
+			}
+		}
+		if sc.NegativeBalances > 0 {
+			fmt.Printf("  %d addresses sent more than they received\n", sc.NegativeBalances)
+		}
+		if sc.BalancesSkipped > 0 {
+			fmt.Printf("  %d holder balances couldn't be read\n", sc.BalancesSkipped)
+		}
+		for _, mismatch := range sc.BalanceMismatches {
+			fmt.Printf("  %s indexed %s, balanceOf %s\n", mismatch.Address,
+				utils.ToDecimal(mismatch.Indexed, token.Decimals).Text('f', 2),
+				utils.ToDecimal(mismatch.Actual, token.Decimals).Text('f', 2))
+		}
+		if len(sc.BalanceMismatches) > 0 {
+			fmt.Printf("  Balance Drift:       %.2f%%\n", sc.BalanceDriftPct)
+		}
+	}
+	if len(token.Clusters) > 0 {
+		largest := token.Clusters[0]
+		fmt.Printf("Largest Cluster:       %.2f%% across %d wallets (%d clusters)\n", largest.Share, len(largest.Members), len(token.Clusters))
+		fmt.Printf("  Linked by: %s\n", strings.Join(largest.Reasons, "; "))
+		for _, member := range largest.Members {
+			fmt.Printf("  %s\n", member)
+		}
+	}
+	if token.MarketCap != nil {
+		fmt.Printf("Market Cap:            $%.2f\n", token.MarketCap)
+		fmt.Printf("Fully Diluted Value:   $%.2f\n", token.FullyDilutedValue)
+	}
+	fmt.Printf("Holders:               %d\n", token.Holders)
+	fmt.Printf("Transfers:             %d\n", token.TokenTransfers)
+	for _, holder := range token.LargestHolders {
+		fmt.Printf("  %s %7.2f%%  %s\n", holder.Address, holder.Share, utils.ToDecimal(holder.Balance, token.Decimals).Text('f', 2))
+	}
+
+	if lp := token.LPAnalysis; lp != nil {
+		fmt.Printf("LP Burned:             %.2f%%\n", lp.BurnedPct)
+		fmt.Printf("LP Locked:             %.2f%%\n", lp.LockedPct)
+		fmt.Printf("LP Lock Expired:       %.2f%%\n", lp.ExpiredLockPct)
+		fmt.Printf("LP Held by Deployer:   %.2f%%\n", lp.DeployerPct)
+		if lp.NextUnlock != nil {
+			fmt.Printf("LP Next Unlock:        %s\n", lp.NextUnlock.Format(time.RFC3339))
+		}
+		for _, holder := range lp.Holders {
+			fmt.Printf("  %s %7.2f%%  %-12s %s\n", holder.Address, holder.Share, holder.Class, holder.Label)
+		}
+	}
+	if sr := token.Snipers; sr != nil && sr.LaunchTxHash != (common.Hash{}) {
+		fmt.Printf("Launch Block:          %d\n", sr.LaunchBlock)
+		fmt.Printf("Sniped Supply:         %.2f%% by %d of %d early buyers\n", sr.SnipedPct, len(sr.Snipers), sr.EarlyBuyers)
+		for _, buyer := range sr.Snipers {
+			fmt.Printf("  %s %7.2f%%  %s\n", buyer.Address, buyer.SupplyPct, strings.Join(buyer.Flags, ", "))
+		}
+	}
+	if hp := token.Honeypot; hp != nil {
+		fmt.Printf("Honeypot:              %t\n", hp.IsHoneypot)
+		fmt.Printf("Buy Tax:               %.2f%%\n", hp.BuyTaxPct)
+		fmt.Printf("Sell Tax:              %.2f%%\n", hp.SellTaxPct)
+		if hp.RevertReason != "" {
+			fmt.Printf("Revert Reason:         %s\n", hp.RevertReason)
+		}
+	}
+	if tr := token.TransferRestrictions; tr != nil && tr.NotSimulated != "" {
+		fmt.Printf("Transfer Restrictions: not simulated, %s\n", tr.NotSimulated)
+	} else if tr != nil {
+		fmt.Printf("Transfer Fee:          %.2f%%\n", tr.TransferFeePct)
+		if tr.MaxTransferAmount != nil {
+			fmt.Printf("Max Transfer:          %s\n", tr.MaxTransferAmount.Text('f', 4))
+		}
+		if tr.MaxWalletAmount != nil {
+			fmt.Printf("Max Wallet:            %s\n", tr.MaxWalletAmount.Text('f', 4))
+		}
+		if tr.SearchLimitPct < 100 {
+			fmt.Printf("Size Search Limit:     %.2f%% of supply\n", tr.SearchLimitPct)
+		}
+		if tr.HasCooldown {
+			if tr.CooldownBlocks > 0 {
+				fmt.Printf("Transfer Cooldown:     %d blocks\n", tr.CooldownBlocks)
+			} else {
+				fmt.Printf("Transfer Cooldown:     more than %d blocks\n", simulation.MaxCooldownBlocks)
+			}
+		}
+		for _, tx := range tr.Evidence {
+			status := "ok"
+			if !tx.Success {
+				status = "failed: " + tx.RevertReason
+			}
+			fmt.Printf("  block %d  %s  %s\n", tx.BlockNumber, tx.Description, status)
+		}
+	}
+	if token.Timeline != nil && len(token.Timeline.Events) > 0 {
+		fmt.Println("Timeline:")
+		printTimelineEvents(token.Timeline, "  ")
+	}
+	fmt.Println()
+}
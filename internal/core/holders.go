@@ -0,0 +1,178 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/store"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	Holders are counted by replaying every Transfer event of the token from its creation
+	block. The index is saved in the store after each run, so later runs only replay the
+	blocks mined since.
+
+	Mints and burns move tokens from and to the zero address, which is never counted as
+	a holder. Transfer logs that don't follow the ERC20 layout (e.g. ERC721 style events
+	with an indexed value) are skipped.
+*/
+
+const (
+	holdersNamespace   = "holders"
+	largestHolderCount = 10
+)
+
+var transferEventID = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// GetHolderData indexes the token's holders and fills its holder fields, returning the
+// index for further analysis
+func GetHolderData(cl *ethclient.Client, st *store.Store, token *types.Token, creationBlock uint64) (*types.HolderIndex, error) {
+	index, err := IndexHolders(cl, st, token.Address, creationBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	totalSupply, err := getRawTotalSupply(cl, token.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	token.Holders = CountHolders(index)
+	token.LargestHolders = GetLargestHolders(index, totalSupply, largestHolderCount)
+	token.TokenTransfers = index.Transfers
+	return index, nil
+}
+
+// getRawTotalSupply returns the total supply before scaling by decimals, since
+// Token.TotalSupply is rounded to whole tokens
+func getRawTotalSupply(cl *ethclient.Client, tokenAddress common.Address) (*big.Int, error) {
+	return getRawTotalSupplyAt(cl, tokenAddress, nil)
+}
+
+// getRawTotalSupplyAt reads the raw total supply at blockNumber, nil for the latest block
+func getRawTotalSupplyAt(cl *ethclient.Client, tokenAddress common.Address, blockNumber *big.Int) (*big.Int, error) {
+	tokenContract, err := contracts.NewERC20(cl, tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+	return tokenContract.TotalSupply(&bind.CallOpts{BlockNumber: blockNumber})
+}
+
+func IndexHolders(cl *ethclient.Client, st *store.Store, tokenAddress common.Address, creationBlock uint64) (*types.HolderIndex, error) {
+	index := &types.HolderIndex{
+		Token:    tokenAddress,
+		Balances: make(map[common.Address]*big.Int),
+	}
+	found, err := st.Load(holdersNamespace, tokenAddress.Hex(), index)
+	if err != nil {
+		return nil, err
+	}
+	fromBlock := creationBlock
+	if found {
+		fromBlock = index.LastBlock + 1
+	}
+
+	toBlock, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get latest block number: %v", err)
+	}
+	if fromBlock > toBlock {
+		return index, nil
+	}
+
+	logs, err := utils.FilterLogs(cl, []common.Address{tokenAddress}, [][]common.Hash{{transferEventID}}, fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get Transfer logs:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+	ApplyTransfers(index, logs)
+	index.LastBlock = toBlock
+
+	if err := st.Save(holdersNamespace, tokenAddress.Hex(), index); err != nil {
+		return nil, fmt.Errorf("\nFailed to save holder index:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+	return index, nil
+}
+
+// ApplyTransfers updates the index balances with Transfer logs, which must be in chain order
+func ApplyTransfers(index *types.HolderIndex, logs []gethtypes.Log) {
+	for _, log := range logs {
+		if len(log.Topics) != 3 || len(log.Data) != 32 || log.Removed {
+			continue
+		}
+		from := common.BytesToAddress(log.Topics[1].Bytes())
+		to := common.BytesToAddress(log.Topics[2].Bytes())
+		value := new(big.Int).SetBytes(log.Data)
+
+		if from != (common.Address{}) {
+			index.Balances[from] = addBalance(index.Balances[from], new(big.Int).Neg(value))
+			if index.Balances[from].Sign() == 0 {
+				delete(index.Balances, from)
+			}
+		}
+		if to != (common.Address{}) && value.Sign() > 0 {
+			index.Balances[to] = addBalance(index.Balances[to], value)
+		}
+		index.Transfers++
+	}
+}
+
+func addBalance(balance, delta *big.Int) *big.Int {
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	return balance.Add(balance, delta)
+}
+
+// CountHolders counts the addresses holding a positive balance
+func CountHolders(index *types.HolderIndex) uint64 {
+	var count uint64
+	for _, balance := range index.Balances {
+		if balance.Sign() > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// GetLargestHolders returns the n largest holders with their share of totalSupply
+func GetLargestHolders(index *types.HolderIndex, totalSupply *big.Int, n int) []types.TokenHolder {
+	var holders []types.TokenHolder
+	for address, balance := range index.Balances {
+		if balance.Sign() > 0 {
+			holders = append(holders, types.TokenHolder{
+				Address: address,
+				Balance: balance,
+				Share:   sharePct(balance, totalSupply),
+			})
+		}
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if cmp := holders[i].Balance.Cmp(holders[j].Balance); cmp != 0 {
+			return cmp > 0
+		}
+		return holders[i].Address.Hex() < holders[j].Address.Hex()
+	})
+	if len(holders) > n {
+		holders = holders[:n]
+	}
+	return holders
+}
+
+func sharePct(amount, total *big.Int) float64 {
+	if total == nil || total.Sign() == 0 {
+		return 0
+	}
+	share := new(big.Float).Quo(new(big.Float).SetInt(amount), new(big.Float).SetInt(total))
+	pct, _ := share.Float64()
+	return pct * 100
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+func GenerateLiquidityTimeline(ethNodeURL string, tokenAddress common.Address) (*types.LiquidityTimeline, error) {
+	cl, err := ethclient.Dial(ethNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
+	}
+
+	pair, err := dexes.GetUniswapPair(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetUniswapPair() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	} else if pair == nil {
+		return nil, fmt.Errorf("\nNo Uniswap V2 WETH pair found:\n\tToken Address: %s", tokenAddress)
+	}
+
+	return GetLiquidityTimeline(cl, pair)
+}
+
+// GetLiquidityTimeline replays the pair's liquidity events from its creation block
+func GetLiquidityTimeline(cl *ethclient.Client, pair *types.UniswapPair) (*types.LiquidityTimeline, error) {
+	creationBlock, err := utils.FindCreationBlock(cl, pair.Address)
+	if err != nil {
+		return nil, fmt.Errorf("\nFindCreationBlock() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+
+	events, err := dexes.GetLiquidityEvents(cl, pair, creationBlock, blockNum)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetLiquidityEvents() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+	return BuildLiquidityTimeline(pair.Address, events), nil
+}
+
+func BuildLiquidityTimeline(pairAddress common.Address, events []*types.LiquidityEvent) *types.LiquidityTimeline {
+	timeline := &types.LiquidityTimeline{
+		PairAddress: pairAddress,
+		Events:      events,
+		LPMinted:    new(big.Int),
+		LPRemoved:   new(big.Int),
+	}
+
+	for _, event := range events {
+		if !event.Add {
+			timeline.Removals = append(timeline.Removals, event)
+			timeline.LPRemoved.Add(timeline.LPRemoved, event.LPAmount)
+			continue
+		}
+
+		timeline.LPMinted.Add(timeline.LPMinted, event.LPAmount)
+		if timeline.InitialTokenLiquidity == nil {
+			timeline.InitialProvider = event.Provider
+			timeline.InitialBlock = event.BlockNumber
+			timeline.InitialTokenLiquidity = event.TokenAmount
+			timeline.InitialWETHLiquidity = event.WETHAmount
+			if event.TokenAmount.Sign() > 0 {
+				timeline.InitialPriceInWETH = new(big.Float).Quo(event.WETHAmount, event.TokenAmount)
+			}
+		}
+	}
+
+	if timeline.LPMinted.Sign() > 0 {
+		removed := new(big.Float).SetInt(timeline.LPRemoved)
+		removedPct, _ := removed.Quo(removed, new(big.Float).SetInt(timeline.LPMinted)).Float64()
+		timeline.RemovedPct = removedPct * 100
+	}
+
+	return timeline
+}
+
+func PrintLiquidityTimeline(timeline *types.LiquidityTimeline) {
+	fmt.Printf("\nPair Address:             %s\n", timeline.PairAddress)
+	if timeline.InitialTokenLiquidity == nil {
+		fmt.Println("No liquidity has been added")
+		return
+	}
+	fmt.Printf("Initial Provider:         %s\n", timeline.InitialProvider)
+	fmt.Printf("Initial Block:            %d\n", timeline.InitialBlock)
+	fmt.Printf("Initial Token Liquidity:  %s\n", timeline.InitialTokenLiquidity.Text('f', 4))
+	fmt.Printf("Initial WETH Liquidity:   %s\n", timeline.InitialWETHLiquidity.Text('f', 6))
+	if timeline.InitialPriceInWETH != nil {
+		fmt.Printf("Initial Price in WETH:    %s\n", timeline.InitialPriceInWETH.Text('g', 12))
+	}
+	fmt.Printf("Removals:                 %d (%.2f%% of minted LP)\n", len(timeline.Removals), timeline.RemovedPct)
+
+	fmt.Printf("\n%-10s %-20s %-6s %-42s %24s %20s %24s\n", "Block", "Time", "Action", "Provider", "Tokens", "WETH", "LP")
+	for _, event := range timeline.Events {
+		action := "REMOVE"
+		if event.Add {
+			action = "ADD"
+		}
+		fmt.Printf("%-10d %-20s %-6s %-42s %24s %20s %24s\n",
+			event.BlockNumber,
+			event.Timestamp.Format("2006-01-02 15:04:05"),
+			action,
+			event.Provider.Hex(),
+			event.TokenAmount.Text('f', 4),
+			event.WETHAmount.Text('f', 6),
+			event.LPAmount.String(),
+		)
+	}
+}
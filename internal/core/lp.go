@@ -0,0 +1,213 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	LP tokens are classified by who holds them:
+
+		- burned:       the zero or dead address
+		- locked:       a locker contract from the lp_lockers registry in the config
+		- expired_lock: LP left in a locker after its locks' unlock dates have passed
+		- deployer:     the token's deployer
+		- contract:     any other contract, including the pair itself while a removal is pending
+		- eoa:          any other externally owned account
+
+	For lockers with a known kind, the pair's locks are read on-chain and only LP covered by a
+	lock whose unlock date is still ahead counts as locked. Whatever the locker holds beyond
+	that can be withdrawn at any time, so it is reported as an expired lock instead. Lockers
+	without a kind are only labelled and their whole balance counts as locked.
+*/
+
+type lpLock struct {
+	amount     *big.Int
+	unlockDate time.Time
+}
+
+func AnalyzeLPHolders(cl *ethclient.Client, pair *types.UniswapPair, deployer common.Address, lockers []config.LockerConfig) (*types.LPAnalysis, error) {
+	creationBlock, err := utils.FindCreationBlock(cl, pair.Address)
+	if err != nil {
+		return nil, fmt.Errorf("\nFindCreationBlock() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+
+	balances, err := dexes.GetLPBalances(cl, pair, creationBlock, blockNum)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetLPBalances() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+
+	var totalSupplyResult []interface{}
+	err = pair.Contract.Call(&bind.CallOpts{}, &totalSupplyResult, "totalSupply")
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to call totalSupply() on pair %s: %v", pair.Address, err)
+	}
+
+	analysis := &types.LPAnalysis{
+		PairAddress: pair.Address,
+		TotalSupply: totalSupplyResult[0].(*big.Int),
+	}
+	if analysis.TotalSupply.Sign() == 0 {
+		return analysis, nil
+	}
+
+	lockersByAddress := make(map[common.Address]config.LockerConfig)
+	for _, locker := range lockers {
+		lockersByAddress[common.HexToAddress(locker.Address)] = locker
+	}
+
+	for address, balance := range balances {
+		if balance.Sign() <= 0 {
+			continue
+		}
+		share, _ := new(big.Float).Quo(new(big.Float).SetInt(balance), new(big.Float).SetInt(analysis.TotalSupply)).Float64()
+		holder := &types.LPHolder{
+			Address: address,
+			Balance: balance,
+			Share:   share * 100,
+		}
+
+		locker, isLocker := lockersByAddress[address]
+		switch {
+		case address == (common.Address{}):
+			holder.Class, holder.Label = types.LPClassBurned, "zero address"
+			analysis.BurnedPct += holder.Share
+		case address == utils.DeadAddress:
+			holder.Class, holder.Label = types.LPClassBurned, "dead address"
+			analysis.BurnedPct += holder.Share
+		case isLocker:
+			holder.Class, holder.Label = types.LPClassLocked, locker.Name
+			locks, known, err := getLockerLocks(cl, locker, pair.Address)
+			if err != nil {
+				return nil, fmt.Errorf("\ngetLockerLocks() failed:\n\tLocker: %s\n\tError: %v", locker.Name, err)
+			}
+			if !known {
+				analysis.LockedPct += holder.Share
+				break
+			}
+
+			activeAmount := new(big.Int)
+			now := time.Now()
+			for _, lock := range locks {
+				if !lock.unlockDate.After(now) {
+					continue
+				}
+				activeAmount.Add(activeAmount, lock.amount)
+				if holder.UnlockDate == nil || lock.unlockDate.Before(*holder.UnlockDate) {
+					unlockDate := lock.unlockDate
+					holder.UnlockDate = &unlockDate
+				}
+			}
+			if activeAmount.Cmp(balance) > 0 {
+				activeAmount.Set(balance)
+			}
+			activeShare, _ := new(big.Float).Quo(new(big.Float).SetInt(activeAmount), new(big.Float).SetInt(analysis.TotalSupply)).Float64()
+			analysis.LockedPct += activeShare * 100
+			analysis.ExpiredLockPct += holder.Share - activeShare*100
+			if activeAmount.Sign() == 0 {
+				holder.Class = types.LPClassExpiredLock
+			}
+			if holder.UnlockDate != nil && (analysis.NextUnlock == nil || holder.UnlockDate.Before(*analysis.NextUnlock)) {
+				analysis.NextUnlock = holder.UnlockDate
+			}
+		case address == deployer:
+			holder.Class, holder.Label = types.LPClassDeployer, "deployer"
+			analysis.DeployerPct += holder.Share
+		default:
+			code, err := cl.CodeAt(context.Background(), address, nil)
+			if err != nil {
+				return nil, fmt.Errorf("\nFailed to get code at %s: %v", address, err)
+			}
+			holder.Class = types.LPClassEOA
+			if len(code) > 0 {
+				holder.Class = types.LPClassContract
+			}
+			if address == pair.Address {
+				holder.Label = "pair"
+			}
+		}
+		analysis.Holders = append(analysis.Holders, holder)
+	}
+
+	sort.Slice(analysis.Holders, func(i, j int) bool {
+		return analysis.Holders[i].Balance.Cmp(analysis.Holders[j].Balance) > 0
+	})
+
+	return analysis, nil
+}
+
+// getLockerLocks returns the pair's unwithdrawn locks in the locker, whether or not their
+// unlock date has passed. known is false when the locker kind can't be read on-chain.
+func getLockerLocks(cl *ethclient.Client, locker config.LockerConfig, pairAddress common.Address) (locks []lpLock, known bool, err error) {
+	lockerAddress := common.HexToAddress(locker.Address)
+
+	switch locker.Kind {
+	case "uncx_v2":
+		known = true
+		parsedABI, err := abi.JSON(strings.NewReader(utils.UNCXV2LockerABI))
+		if err != nil {
+			return nil, false, fmt.Errorf("\nFailed to parse UNCXV2LockerABI: %v", err)
+		}
+		contract := bind.NewBoundContract(lockerAddress, parsedABI, cl, cl, cl)
+
+		var numLocksResult []interface{}
+		err = contract.Call(&bind.CallOpts{}, &numLocksResult, "getNumLocksForToken", pairAddress)
+		if err != nil {
+			return nil, false, fmt.Errorf("\nFailed to call getNumLocksForToken(): %v", err)
+		}
+		numLocks := numLocksResult[0].(*big.Int).Int64()
+		for i := int64(0); i < numLocks; i++ {
+			var lockResult []interface{}
+			err = contract.Call(&bind.CallOpts{}, &lockResult, "tokenLocks", pairAddress, big.NewInt(i))
+			if err != nil {
+				return nil, false, fmt.Errorf("\nFailed to call tokenLocks(): %v", err)
+			}
+			if amount := lockResult[1].(*big.Int); amount.Sign() > 0 {
+				locks = append(locks, lpLock{amount: amount, unlockDate: time.Unix(lockResult[3].(*big.Int).Int64(), 0).UTC()})
+			}
+		}
+
+	case "team_finance":
+		known = true
+		parsedABI, err := abi.JSON(strings.NewReader(utils.TeamFinanceLockerABI))
+		if err != nil {
+			return nil, false, fmt.Errorf("\nFailed to parse TeamFinanceLockerABI: %v", err)
+		}
+		contract := bind.NewBoundContract(lockerAddress, parsedABI, cl, cl, cl)
+
+		var depositsResult []interface{}
+		err = contract.Call(&bind.CallOpts{}, &depositsResult, "getDepositsByTokenAddress", pairAddress)
+		if err != nil {
+			return nil, false, fmt.Errorf("\nFailed to call getDepositsByTokenAddress(): %v", err)
+		}
+		for _, id := range depositsResult[0].([]*big.Int) {
+			var lockResult []interface{}
+			err = contract.Call(&bind.CallOpts{}, &lockResult, "lockedToken", id)
+			if err != nil {
+				return nil, false, fmt.Errorf("\nFailed to call lockedToken(): %v", err)
+			}
+			if !lockResult[4].(bool) {
+				locks = append(locks, lpLock{amount: lockResult[2].(*big.Int), unlockDate: time.Unix(lockResult[3].(*big.Int).Int64(), 0).UTC()})
+			}
+		}
+	}
+
+	return locks, known, nil
+}
@@ -0,0 +1,426 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/store"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	Profiled tokens are tracked in the store, and each monitor run only evaluates the
+	blocks after the last one it saw. The checks over those blocks are:
+
+		liquidity_removed  a Burn that took at least LiquidityRemovalPct of the WETH reserve
+		price_crash        the price falling PriceDropPct within PriceWindow
+		large_mint         a mint of at least MintPct of the supply
+		hidden_mint        totalSupply() growing at least MintPct more than the mints and
+				   burns over the blocks account for
+		owner_sell         a sell sent by the deployer or owner
+
+	All but owner_sell are rug triggers: they mark the token rugged, which is final, and
+	rugged tokens aren't evaluated again. Owner sells are kept as evidence without
+	changing the status, and only the last maxEvidence pieces of evidence are kept. A
+	token that isn't rugged and hasn't traded for AbandonAfter is abandoned until trading
+	picks up again. Time is measured in block time, so a run that catches up on old blocks
+	classifies them as of when they happened.
+*/
+
+const (
+	trackedNamespace = "tracked"
+	maxEvidence      = 50
+
+	EvidenceLiquidityRemoved = "liquidity_removed"
+	EvidencePriceCrash       = "price_crash"
+	EvidenceLargeMint        = "large_mint"
+	EvidenceHiddenMint       = "hidden_mint"
+	EvidenceOwnerSell        = "owner_sell"
+	EvidenceNoTrades         = "no_trades"
+)
+
+type MonitorOptions struct {
+	// LiquidityRemovalPct is the share of the WETH reserve one removal must take
+	LiquidityRemovalPct float64
+	// PriceDropPct is how far the price must fall within PriceWindow
+	PriceDropPct float64
+	PriceWindow  time.Duration
+	// MintPct is the share of the supply one mint must add
+	MintPct float64
+	// AbandonAfter is how long without trades before a token counts as abandoned
+	AbandonAfter time.Duration
+}
+
+// GenerateMonitorReport starts tracking the given tokens and brings every tracked token
+// up to the latest block
+func GenerateMonitorReport(conf config.Config, tokenAddresses []common.Address, opts MonitorOptions) ([]*types.TrackedToken, error) {
+	cl, err := ethclient.Dial(conf.EthNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
+	}
+	// The monitor command calls this on every interval, so the connection can't outlive it
+	defer cl.Close()
+	st, err := store.Open(conf.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("\nstore.Open() failed: %v", err)
+	}
+
+	var tracked []*types.TrackedToken
+	for _, tokenAddress := range tokenAddresses {
+		state, err := startTracking(cl, st, tokenAddress)
+		if err != nil {
+			return nil, fmt.Errorf("\nstartTracking() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+		}
+		tracked = append(tracked, state)
+	}
+
+	keys, err := st.Keys(trackedNamespace)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[common.Address]bool)
+	for _, state := range tracked {
+		seen[state.Token] = true
+	}
+	for _, key := range keys {
+		state := &types.TrackedToken{}
+		if _, err := st.Load(trackedNamespace, key, state); err != nil {
+			return nil, err
+		}
+		if !seen[state.Token] {
+			seen[state.Token] = true
+			tracked = append(tracked, state)
+		}
+	}
+
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+	for _, state := range tracked {
+		err := UpdateTrackedToken(cl, state, blockNum, opts)
+		if err != nil {
+			return nil, fmt.Errorf("\nUpdateTrackedToken() failed:\n\tToken Address: %s\n\tError: %v", state.Token, err)
+		}
+		if err := st.Save(trackedNamespace, state.Token.Hex(), state); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(tracked, func(i, j int) bool {
+		return tracked[i].TrackedSince.Before(tracked[j].TrackedSince)
+	})
+	return tracked, nil
+}
+
+// TrackToken starts tracking a profiled token from the current block, leaving tokens
+// that are already tracked alone
+func TrackToken(cl *ethclient.Client, st *store.Store, token *types.Token) error {
+	var existing types.TrackedToken
+	if found, err := st.Load(trackedNamespace, token.Address.Hex(), &existing); err != nil || found {
+		return err
+	}
+	var owner common.Address
+	if token.Ownership != nil && !token.Ownership.Renounced {
+		owner = token.Ownership.Owner
+	}
+	state, err := newTrackedToken(cl, token.Address, token.Symbol, token.Decimals, token.ContractCreator, owner)
+	if err != nil {
+		return err
+	}
+	return st.Save(trackedNamespace, token.Address.Hex(), state)
+}
+
+func startTracking(cl *ethclient.Client, st *store.Store, tokenAddress common.Address) (*types.TrackedToken, error) {
+	state := &types.TrackedToken{}
+	if found, err := st.Load(trackedNamespace, tokenAddress.Hex(), state); err != nil || found {
+		return state, err
+	}
+
+	token, err := contracts.GetBasicContractData(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetBasicContractData() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	} else if token == nil {
+		return nil, fmt.Errorf("\nNot an ERC20 token:\n\tToken Address: %s", tokenAddress)
+	}
+	creation, err := contracts.GetContractCreator(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetContractCreator() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+	ownership, err := contracts.GetOwnership(cl, tokenAddress, creation.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetOwnership() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+	var owner common.Address
+	if !ownership.Renounced {
+		owner = ownership.Owner
+	}
+
+	state, err = newTrackedToken(cl, tokenAddress, token.Symbol, token.Decimals, creation.Creator, owner)
+	if err != nil {
+		return nil, err
+	}
+	return state, st.Save(trackedNamespace, tokenAddress.Hex(), state)
+}
+
+func newTrackedToken(cl *ethclient.Client, tokenAddress common.Address, symbol string, decimals uint8, deployer, owner common.Address) (*types.TrackedToken, error) {
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+	now, err := utils.GetBlockTime(cl, blockNum, nil)
+	if err != nil {
+		return nil, err
+	}
+	totalSupply, err := getRawTotalSupplyAt(cl, tokenAddress, new(big.Int).SetUint64(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	return &types.TrackedToken{
+		Token:        tokenAddress,
+		Symbol:       symbol,
+		Decimals:     decimals,
+		Deployer:     deployer,
+		Owner:        owner,
+		TrackedSince: now,
+		LastBlock:    blockNum,
+		LastChecked:  now,
+		LastActivity: now,
+		TotalSupply:  totalSupply,
+		Status:       types.TokenStatusActive,
+		StatusSince:  now,
+	}, nil
+}
+
+// UpdateTrackedToken evaluates the blocks after state.LastBlock up to toBlock and
+// reclassifies the token
+func UpdateTrackedToken(cl *ethclient.Client, state *types.TrackedToken, toBlock uint64, opts MonitorOptions) error {
+	if toBlock <= state.LastBlock || state.Status == types.TokenStatusRugged {
+		return nil
+	}
+	fromBlock := state.LastBlock + 1
+	now, err := utils.GetBlockTime(cl, toBlock, nil)
+	if err != nil {
+		return err
+	}
+
+	var triggers []types.MonitorEvidence
+	addEvidence := func(evidence types.MonitorEvidence, trigger bool) {
+		state.Evidence = append(state.Evidence, evidence)
+		if trigger {
+			triggers = append(triggers, evidence)
+		}
+	}
+
+	pair, err := dexes.GetUniswapPair(cl, state.Token)
+	if err != nil {
+		return fmt.Errorf("\nGetUniswapPair() failed:\n\tToken Address: %s\n\tError: %v", state.Token, err)
+	}
+	if pair != nil {
+		history, err := dexes.GetReserveHistory(cl, pair, fromBlock, toBlock)
+		if err != nil {
+			return fmt.Errorf("\nGetReserveHistory() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+		}
+
+		events, err := dexes.GetLiquidityEvents(cl, pair, fromBlock, toBlock)
+		if err != nil {
+			return fmt.Errorf("\nGetLiquidityEvents() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+		}
+		for _, event := range events {
+			if event.Add {
+				continue
+			}
+			removedPct := removedReservePct(event, history)
+			if removedPct >= opts.LiquidityRemovalPct {
+				addEvidence(types.MonitorEvidence{
+					Kind:        EvidenceLiquidityRemoved,
+					BlockNumber: event.BlockNumber,
+					Timestamp:   event.Timestamp,
+					TxHash:      event.TxHash,
+					Detail:      fmt.Sprintf("%s removed %s WETH (%.1f%% of the reserve)", event.Provider, event.WETHAmount.Text('f', 4), removedPct),
+				}, true)
+			}
+		}
+
+		// Reserves from the previous run give drops that started before fromBlock a peak
+		combined := append(append([]*types.Reserves(nil), state.RecentReserves...), history...)
+		for _, drop := range detectPriceDrops(combined, opts.PriceDropPct, opts.PriceWindow) {
+			if drop.BlockNumber < fromBlock {
+				continue
+			}
+			addEvidence(types.MonitorEvidence{
+				Kind:        EvidencePriceCrash,
+				BlockNumber: drop.BlockNumber,
+				Timestamp:   drop.Timestamp,
+				TxHash:      drop.TxHash,
+				Detail:      fmt.Sprintf("price fell %.1f%% within %s", drop.DropPct, opts.PriceWindow),
+			}, true)
+		}
+		state.RecentReserves = nil
+		for _, reserves := range combined {
+			if now.Sub(reserves.Timestamp) <= opts.PriceWindow {
+				state.RecentReserves = append(state.RecentReserves, reserves)
+			}
+		}
+
+		swaps, err := dexes.GetUniswapSwaps(cl, pair, fromBlock, toBlock)
+		if err != nil {
+			return fmt.Errorf("\nGetUniswapSwaps() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+		}
+		for _, swap := range swaps {
+			state.LastActivity = swap.Timestamp
+			if swap.Buy || (state.Deployer == (common.Address{}) && state.Owner == (common.Address{})) {
+				continue
+			}
+			trader, err := utils.GetTransactionSender(cl, swap.TxHash)
+			if err != nil {
+				return err
+			}
+			if trader != state.Deployer && trader != state.Owner {
+				continue
+			}
+			addEvidence(types.MonitorEvidence{
+				Kind:        EvidenceOwnerSell,
+				BlockNumber: swap.BlockNumber,
+				Timestamp:   swap.Timestamp,
+				TxHash:      swap.TxHash,
+				Detail:      fmt.Sprintf("%s sold %s tokens for %s WETH", trader, swap.TokenAmount.Text('f', 2), swap.WETHAmount.Text('f', 4)),
+			}, false)
+		}
+	}
+
+	supplyEvents, err := getSupplyEvents(cl, state.Token, fromBlock, toBlock)
+	if err != nil {
+		return err
+	}
+	expectedSupply := new(big.Int).Set(state.TotalSupply)
+	for _, event := range supplyEvents {
+		if !event.Mint {
+			expectedSupply.Sub(expectedSupply, event.Value)
+			continue
+		}
+		expectedSupply.Add(expectedSupply, event.Value)
+		mintPct := sharePct(event.Value, state.TotalSupply)
+		if mintPct >= opts.MintPct {
+			addEvidence(types.MonitorEvidence{
+				Kind:        EvidenceLargeMint,
+				BlockNumber: event.BlockNumber,
+				Timestamp:   event.Timestamp,
+				TxHash:      event.TxHash,
+				Detail:      fmt.Sprintf("%s tokens minted to %s (%.1f%% of the supply)", utils.ToDecimal(event.Value, state.Decimals).Text('f', 2), event.Account, mintPct),
+			}, true)
+		}
+	}
+	previousSupply := state.TotalSupply
+	// Read at toBlock, so mints after the last event checked aren't taken as hidden
+	state.TotalSupply, err = getRawTotalSupplyAt(cl, state.Token, new(big.Int).SetUint64(toBlock))
+	if err != nil {
+		return err
+	}
+	// Supply that grew past what the mints account for came from somewhere else
+	if hidden := new(big.Int).Sub(state.TotalSupply, expectedSupply); hidden.Sign() > 0 {
+		hiddenPct := sharePct(hidden, previousSupply)
+		if hiddenPct >= opts.MintPct {
+			addEvidence(types.MonitorEvidence{
+				Kind:        EvidenceHiddenMint,
+				BlockNumber: toBlock,
+				Timestamp:   now,
+				Detail:      fmt.Sprintf("supply grew %s tokens without a mint (%.1f%% of the supply)", utils.ToDecimal(hidden, state.Decimals).Text('f', 2), hiddenPct),
+			}, true)
+		}
+	}
+
+	switch {
+	case len(triggers) > 0:
+		state.Status = types.TokenStatusRugged
+		state.StatusSince = triggers[0].Timestamp
+	case now.Sub(state.LastActivity) > opts.AbandonAfter:
+		if state.Status != types.TokenStatusAbandoned {
+			state.Status = types.TokenStatusAbandoned
+			state.StatusSince = state.LastActivity.Add(opts.AbandonAfter)
+			state.Evidence = append(state.Evidence, types.MonitorEvidence{
+				Kind:        EvidenceNoTrades,
+				BlockNumber: toBlock,
+				Timestamp:   now,
+				Detail:      "no trades since " + state.LastActivity.Format(time.RFC3339),
+			})
+		}
+	case state.Status != types.TokenStatusActive:
+		state.Status = types.TokenStatusActive
+		state.StatusSince = state.LastActivity
+	}
+
+	if len(state.Evidence) > maxEvidence {
+		state.Evidence = state.Evidence[len(state.Evidence)-maxEvidence:]
+	}
+	state.LastBlock = toBlock
+	state.LastChecked = now
+	return nil
+}
+
+// removedReservePct is the share of the WETH reserve a removal took, using the reserve
+// the pair synced to in the same transaction
+func removedReservePct(event *types.LiquidityEvent, history []*types.Reserves) float64 {
+	var after *big.Float
+	for _, reserves := range history {
+		if reserves.TxHash == event.TxHash {
+			after = reserves.WETHReserve
+		}
+	}
+	if after == nil {
+		return 0
+	}
+	before := new(big.Float).Add(after, event.WETHAmount)
+	if before.Sign() == 0 {
+		return 0
+	}
+	pct, _ := new(big.Float).Quo(event.WETHAmount, before).Float64()
+	return pct * 100
+}
+
+// detectPriceDrops flags every point where the price fell by at least thresholdPct from
+// its highest value within the preceding window
+func detectPriceDrops(history []*types.Reserves, thresholdPct float64, window time.Duration) []*types.PriceDrop {
+	var drops []*types.PriceDrop
+	price := func(reserves *types.Reserves) *big.Float { return reserves.PriceInWETH }
+	for _, drop := range detectReserveDrops(history, price, thresholdPct, window) {
+		drops = append(drops, &types.PriceDrop{
+			BlockNumber: drop.point.BlockNumber,
+			Timestamp:   drop.point.Timestamp,
+			TxHash:      drop.point.TxHash,
+			FromPrice:   drop.peak.PriceInWETH,
+			ToPrice:     drop.point.PriceInWETH,
+			DropPct:     drop.dropPct,
+		})
+	}
+	return drops
+}
+
+func PrintMonitorReport(tracked []*types.TrackedToken) {
+	fmt.Printf("\n%-42s %-10s %-10s %-20s %-20s %s\n", "Token", "Symbol", "Status", "Since", "Last Trade", "Evidence")
+	for _, state := range tracked {
+		fmt.Printf("%-42s %-10s %-10s %-20s %-20s %d\n",
+			state.Token.Hex(),
+			state.Symbol,
+			state.Status,
+			state.StatusSince.Format("2006-01-02 15:04:05"),
+			state.LastActivity.Format("2006-01-02 15:04:05"),
+			len(state.Evidence),
+		)
+		if state.Status == types.TokenStatusActive {
+			continue
+		}
+		for _, evidence := range state.Evidence {
+			fmt.Printf("  %-10d %-20s %-18s %s\n", evidence.BlockNumber, evidence.Timestamp.Format("2006-01-02 15:04:05"), evidence.Kind, evidence.Detail)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+// GenerateReserveHistory replays the Sync events of the token's Uniswap V2 WETH pair
+// from the pair's creation block, or fromBlock when it is non-zero
+func GenerateReserveHistory(ethNodeURL string, tokenAddress common.Address, fromBlock uint64) ([]*types.Reserves, error) {
+	cl, err := ethclient.Dial(ethNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
+	}
+
+	pair, err := dexes.GetUniswapPair(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetUniswapPair() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	} else if pair == nil {
+		return nil, fmt.Errorf("\nNo Uniswap V2 WETH pair found:\n\tToken Address: %s", tokenAddress)
+	}
+
+	if fromBlock == 0 {
+		fromBlock, err = utils.FindCreationBlock(cl, pair.Address)
+		if err != nil {
+			return nil, fmt.Errorf("\nFindCreationBlock() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+		}
+	}
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+
+	history, err := dexes.GetReserveHistory(cl, pair, fromBlock, blockNum)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetReserveHistory() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+	return history, nil
+}
+
+// DetectLiquidityDrops flags every point where the WETH reserve fell by at least
+// thresholdPct from its highest value within the preceding window
+func DetectLiquidityDrops(history []*types.Reserves, thresholdPct float64, window time.Duration) []*types.LiquidityDrop {
+	var drops []*types.LiquidityDrop
+	wethReserve := func(reserves *types.Reserves) *big.Float { return reserves.WETHReserve }
+	for _, drop := range detectReserveDrops(history, wethReserve, thresholdPct, window) {
+		drops = append(drops, &types.LiquidityDrop{
+			BlockNumber:     drop.point.BlockNumber,
+			Timestamp:       drop.point.Timestamp,
+			TxHash:          drop.point.TxHash,
+			FromWETHReserve: drop.peak.WETHReserve,
+			ToWETHReserve:   drop.point.WETHReserve,
+			DropPct:         drop.dropPct,
+		})
+	}
+	return drops
+}
+
+type reserveDrop struct {
+	peak    *types.Reserves
+	point   *types.Reserves
+	dropPct float64
+}
+
+// detectReserveDrops flags every point where value fell by at least thresholdPct from
+// its highest value within the preceding window, skipping points where value is nil
+func detectReserveDrops(history []*types.Reserves, value func(*types.Reserves) *big.Float, thresholdPct float64, window time.Duration) []reserveDrop {
+	var drops []reserveDrop
+	lastPeak := -1
+	for i, reserves := range history {
+		current := value(reserves)
+		if current == nil {
+			continue
+		}
+		peakIndex := -1
+		for j := i - 1; j >= 0 && reserves.Timestamp.Sub(history[j].Timestamp) <= window; j-- {
+			if v := value(history[j]); v != nil && (peakIndex < 0 || v.Cmp(value(history[peakIndex])) > 0) {
+				peakIndex = j
+			}
+		}
+		if peakIndex < 0 || value(history[peakIndex]).Sign() == 0 {
+			continue
+		}
+		dropPct := dropFromPeakPct(value(history[peakIndex]), current)
+		if dropPct < thresholdPct {
+			continue
+		}
+
+		// Only report the first point of a drop, not every point that stays below the peak
+		if peakIndex == lastPeak {
+			continue
+		}
+		lastPeak = peakIndex
+		drops = append(drops, reserveDrop{peak: history[peakIndex], point: reserves, dropPct: dropPct})
+	}
+	return drops
+}
+
+func ExportReserveHistory(history []*types.Reserves, format string, path string) error {
+	switch format {
+	case "json":
+		return utils.WriteJSON(path, history)
+	case "csv":
+		header := []string{"block", "timestamp", "tx_hash", "token_reserve", "weth_reserve", "price_in_weth"}
+		var rows [][]string
+		for _, reserves := range history {
+			price := ""
+			if reserves.PriceInWETH != nil {
+				price = reserves.PriceInWETH.Text('g', 18)
+			}
+			rows = append(rows, []string{
+				strconv.FormatUint(reserves.BlockNumber, 10),
+				reserves.Timestamp.Format(time.RFC3339),
+				reserves.TxHash.Hex(),
+				reserves.TokenReserve.Text('f', 6),
+				reserves.WETHReserve.Text('f', 18),
+				price,
+			})
+		}
+		return utils.WriteCSV(path, header, rows)
+	default:
+		return fmt.Errorf("\nUnsupported export format: %s", format)
+	}
+}
+
+func PlotReserveHistory(history []*types.Reserves, width, height int) string {
+	var prices, wethReserves []float64
+	for _, reserves := range history {
+		weth, _ := reserves.WETHReserve.Float64()
+		wethReserves = append(wethReserves, weth)
+		if reserves.PriceInWETH != nil {
+			price, _ := reserves.PriceInWETH.Float64()
+			prices = append(prices, price)
+		}
+	}
+	return utils.PlotSeries("Price (WETH)", prices, width, height) + "\n" +
+		utils.PlotSeries("WETH Reserve", wethReserves, width, height)
+}
@@ -0,0 +1,346 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+/*
+	The risk score adds up one factor per signal. Each factor rates its signal with a
+	severity from 0 to 1 and contributes severity * weight points, and the total is capped
+	at 100. Weights are the most points a factor can contribute, so a honeypot alone
+	scores 100 while a high sell tax adds up to 12. The default weights of the other
+	factors add up to 100, so a token only reaches 100 without being a honeypot when
+	every other signal is at its worst.
+
+	Signals that weren't collected for a token contribute nothing and say so in their
+	evidence. Weights can be overridden per factor name with risk_weights in the config,
+	and a weight of 0 turns a factor off.
+*/
+
+const (
+	RiskHoneypot             = "honeypot"
+	RiskSellTax              = "sell_tax"
+	RiskBuyTax               = "buy_tax"
+	RiskTransferRestrictions = "transfer_restrictions"
+	RiskOwnership            = "ownership"
+	RiskCapabilities         = "capabilities"
+	RiskProxy                = "proxy"
+	RiskLPUnlocked           = "lp_unlocked"
+	RiskConcentration        = "holder_concentration"
+	RiskClusters             = "wallet_clusters"
+	RiskSnipers              = "snipers"
+	RiskDeployerHistory      = "deployer_history"
+	RiskDeployerFunding      = "deployer_funding"
+	RiskSourcePatterns       = "source_patterns"
+	RiskCloneFamily          = "clone_family"
+	RiskSupplyInconsistency  = "supply_inconsistency"
+)
+
+var DefaultRiskWeights = map[string]float64{
+	RiskHoneypot:             100,
+	RiskSellTax:              12,
+	RiskBuyTax:               6,
+	RiskTransferRestrictions: 5,
+	RiskOwnership:            7,
+	RiskCapabilities:         9,
+	RiskProxy:                5,
+	RiskLPUnlocked:           10,
+	RiskConcentration:        6,
+	RiskClusters:             5,
+	RiskSnipers:              5,
+	RiskDeployerHistory:      8,
+	RiskDeployerFunding:      4,
+	RiskSourcePatterns:       6,
+	RiskCloneFamily:          6,
+	RiskSupplyInconsistency:  6,
+}
+
+// capabilitySeverity rates how much control a privileged function gives the owner
+var capabilitySeverity = map[string]float64{
+	"mint":                           0.5,
+	"owner-transfer":                 0.6,
+	"blacklist":                      0.4,
+	"fee-setter":                     0.3,
+	"pause":                          0.3,
+	"trading-toggle":                 0.2,
+	"max-tx-setter":                  0.1,
+	"whitelist":                      0.1,
+	"upgradeable":                    0.4,
+	contracts.CapabilitySelfDestruct: 0.5,
+	contracts.CapabilityDelegateCall: 0.3,
+}
+
+// sourcePatternSeverity rates the scam patterns found in verified source
+var sourcePatternSeverity = map[string]float64{
+	contracts.PatternOwnerTransferBranch: 0.3,
+	contracts.PatternBlacklistMapping:    0.4,
+	contracts.PatternUncappedFee:         0.5,
+	contracts.PatternHardcodedExempt:     0.3,
+	contracts.PatternObfuscatedCall:      0.7,
+}
+
+var supplyFlagSeverity = map[string]float64{
+	SupplyFlagHiddenMint:   1,
+	SupplyFlagHiddenBurn:   0.3,
+	SupplyFlagBalanceDrift: 0.2,
+}
+
+var ownerKindSeverity = map[string]float64{
+	types.OwnerKindNone:     0,
+	types.OwnerKindTimelock: 0.2,
+	types.OwnerKindMultisig: 0.3,
+	types.OwnerKindContract: 0.7,
+	types.OwnerKindEOA:      1,
+}
+
+type riskFactorFunc func(token *types.Token) (severity float64, evidence string, ok bool)
+
+var riskFactors = []struct {
+	name     string
+	evaluate riskFactorFunc
+}{
+	{RiskHoneypot, func(t *types.Token) (float64, string, bool) {
+		if t.Honeypot == nil {
+			return 0, "", false
+		}
+		hp := t.Honeypot
+		switch {
+		case !hp.IsHoneypot:
+			return 0, "round trip trade succeeded", true
+		case hp.BuyReverted:
+			return 1, "buy failed in simulation: " + hp.RevertReason, true
+		case hp.SellReverted:
+			return 1, "sell failed in simulation: " + hp.RevertReason, true
+		case hp.BuyTaxPct >= 100:
+			return 1, "buy returned no tokens in simulation", true
+		}
+		return 1, fmt.Sprintf("%.2f%% sell tax in simulation", hp.SellTaxPct), true
+	}},
+	{RiskSellTax, func(t *types.Token) (float64, string, bool) {
+		if t.Honeypot == nil || t.Honeypot.SellReverted {
+			return 0, "", false
+		}
+		return clamp(t.Honeypot.SellTaxPct / 50), fmt.Sprintf("%.2f%% sell tax", t.Honeypot.SellTaxPct), true
+	}},
+	{RiskBuyTax, func(t *types.Token) (float64, string, bool) {
+		if t.Honeypot == nil || t.Honeypot.BuyReverted {
+			return 0, "", false
+		}
+		return clamp(t.Honeypot.BuyTaxPct / 50), fmt.Sprintf("%.2f%% buy tax", t.Honeypot.BuyTaxPct), true
+	}},
+	{RiskTransferRestrictions, func(t *types.Token) (float64, string, bool) {
+		tr := t.TransferRestrictions
+		if tr == nil || tr.NotSimulated != "" {
+			return 0, "", false
+		}
+		var severity float64
+		var evidence []string
+		if tr.TransferFeePct > 0 {
+			severity += clamp(tr.TransferFeePct / 25)
+			evidence = append(evidence, fmt.Sprintf("%.2f%% transfer fee", tr.TransferFeePct))
+		}
+		if tr.MaxTransferAmount != nil {
+			severity += 0.3
+			evidence = append(evidence, "max transfer "+tr.MaxTransferAmount.Text('f', 2))
+		}
+		if tr.MaxWalletAmount != nil {
+			severity += 0.3
+			evidence = append(evidence, "max wallet "+tr.MaxWalletAmount.Text('f', 2))
+		}
+		if tr.HasCooldown {
+			severity += 0.3
+			evidence = append(evidence, "transfer cooldown")
+		}
+		if len(evidence) == 0 {
+			if tr.SearchLimitPct < 100 {
+				return 0, fmt.Sprintf("no transfer restrictions up to %.2f%% of the supply", tr.SearchLimitPct), true
+			}
+			return 0, "no transfer restrictions", true
+		}
+		return clamp(severity), strings.Join(evidence, ", "), true
+	}},
+	{RiskOwnership, func(t *types.Token) (float64, string, bool) {
+		o := t.Ownership
+		if o == nil {
+			return 0, "", false
+		}
+		switch {
+		case o.Source == "":
+			return 0, "no owner found", true
+		case o.Renounced:
+			return 0, "ownership renounced", true
+		}
+		return ownerKindSeverity[o.OwnerKind], fmt.Sprintf("owned by %s %s", o.OwnerKind, o.Owner.Hex()), true
+	}},
+	{RiskCapabilities, func(t *types.Token) (float64, string, bool) {
+		if t.Capabilities == nil {
+			return 0, "no privileged functions found", true
+		}
+		var severity float64
+		var names []string
+		seen := make(map[string]bool)
+		for _, capability := range t.Capabilities {
+			if !seen[capability.Name] {
+				seen[capability.Name] = true
+				severity += capabilitySeverity[capability.Name]
+				names = append(names, capability.Name)
+			}
+		}
+		// Privileges are harmless once nobody can call them
+		if o := t.Ownership; o != nil && o.Renounced {
+			severity /= 4
+			names = append(names, "(owner renounced)")
+		}
+		return clamp(severity), strings.Join(names, ", "), true
+	}},
+	{RiskProxy, func(t *types.Token) (float64, string, bool) {
+		if t.Proxy == nil {
+			return 0, "not a proxy", true
+		}
+		if !t.Proxy.Upgradeable {
+			return 0.2, fmt.Sprintf("%s proxy to %s", t.Proxy.Kind, t.Proxy.Implementation.Hex()), true
+		}
+		return 1, fmt.Sprintf("upgradeable %s proxy to %s", t.Proxy.Kind, t.Proxy.Implementation.Hex()), true
+	}},
+	{RiskLPUnlocked, func(t *types.Token) (float64, string, bool) {
+		lp := t.LPAnalysis
+		if lp == nil {
+			return 0, "", false
+		}
+		secured := lp.BurnedPct + lp.LockedPct
+		return clamp(1 - secured/100), fmt.Sprintf("%.2f%% of LP burned, %.2f%% locked", lp.BurnedPct, lp.LockedPct), true
+	}},
+	{RiskConcentration, func(t *types.Token) (float64, string, bool) {
+		c := t.Concentration
+		if c == nil {
+			return 0, "", false
+		}
+		return clamp((c.Top10Pct - 20) / 60), fmt.Sprintf("top 10 holders hold %.2f%%, Gini %.3f", c.Top10Pct, c.Gini), true
+	}},
+	{RiskClusters, func(t *types.Token) (float64, string, bool) {
+		if t.Clusters == nil {
+			return 0, "no linked holders", true
+		}
+		largest := t.Clusters[0]
+		return clamp(largest.Share / 30), fmt.Sprintf("%d linked wallets hold %.2f%%", len(largest.Members), largest.Share), true
+	}},
+	{RiskSnipers, func(t *types.Token) (float64, string, bool) {
+		if t.Snipers == nil {
+			return 0, "", false
+		}
+		return clamp(t.Snipers.SnipedPct / 30), fmt.Sprintf("%d snipers bought %.2f%%", len(t.Snipers.Snipers), t.Snipers.SnipedPct), true
+	}},
+	{RiskDeployerHistory, func(t *types.Token) (float64, string, bool) {
+		d := t.Deployer
+		if d == nil {
+			return 0, "", false
+		}
+		evidence := fmt.Sprintf("%d of %d earlier tokens rugged", d.RuggedCount, d.TokenCount)
+		if d.FirstActivity == nil {
+			return 0.3, evidence + ", fresh address", true
+		}
+		return clamp(float64(d.RuggedCount) * 0.5), evidence, true
+	}},
+	{RiskDeployerFunding, func(t *types.Token) (float64, string, bool) {
+		if t.Deployer == nil || t.Deployer.Funding == nil {
+			return 0, "", false
+		}
+		funding := t.Deployer.Funding
+		source := describeFundingSource(funding)
+		if funding.SourceCategory == labels.CategoryMixer {
+			return 1, "funded from a mixer: " + source, true
+		}
+		return 0, source, true
+	}},
+	{RiskSourcePatterns, func(t *types.Token) (float64, string, bool) {
+		if t.SourceScan == nil {
+			return 0, "", false
+		}
+		var severity float64
+		var patterns []string
+		seen := make(map[string]bool)
+		for _, finding := range t.SourceScan.Findings {
+			if !seen[finding.Pattern] {
+				seen[finding.Pattern] = true
+				severity += sourcePatternSeverity[finding.Pattern]
+				patterns = append(patterns, finding.Pattern)
+			}
+		}
+		if len(patterns) == 0 {
+			return 0, "no scam patterns in source", true
+		}
+		return clamp(severity), strings.Join(patterns, ", "), true
+	}},
+	{RiskCloneFamily, func(t *types.Token) (float64, string, bool) {
+		if t.CodeHash == (common.Hash{}) {
+			return 0, "", false
+		}
+		if t.Clone == nil {
+			return 0, "no earlier deployments of this code", true
+		}
+		// The share of earlier copies of this code that rugged
+		c := t.Clone
+		severity := float64(c.RuggedCount) / float64(c.PriorDeployments)
+		return clamp(severity), describeCloneFamily(c), true
+	}},
+	{RiskSupplyInconsistency, func(t *types.Token) (float64, string, bool) {
+		if t.SupplyCheck == nil {
+			return 0, "", false
+		}
+		if len(t.SupplyCheck.Flags) == 0 {
+			return 0, "supply matches mint and burn events", true
+		}
+		var severity float64
+		for _, flag := range t.SupplyCheck.Flags {
+			severity += supplyFlagSeverity[flag]
+		}
+		return clamp(severity), strings.Join(t.SupplyCheck.Flags, ", "), true
+	}},
+}
+
+// ScoreRisk combines the token's signals into a 0-100 score, using weights on top of
+// DefaultRiskWeights
+func ScoreRisk(token *types.Token, weights map[string]float64) *types.RiskScore {
+	score := &types.RiskScore{}
+	for _, factor := range riskFactors {
+		weight := DefaultRiskWeights[factor.name]
+		if w, ok := weights[factor.name]; ok {
+			weight = w
+		}
+		if weight == 0 {
+			continue
+		}
+
+		severity, evidence, ok := factor.evaluate(token)
+		if !ok {
+			evidence = "not available"
+		}
+		contribution := weight * severity
+		score.Score += contribution
+		score.Factors = append(score.Factors, types.RiskFactor{
+			Name:         factor.name,
+			Weight:       weight,
+			Severity:     severity,
+			Contribution: contribution,
+			Evidence:     evidence,
+		})
+	}
+	score.Score = math.Min(score.Score, 100)
+
+	sort.SliceStable(score.Factors, func(i, j int) bool {
+		return score.Factors[i].Contribution > score.Factors[j].Contribution
+	})
+	return score
+}
+
+func clamp(severity float64) float64 {
+	return math.Max(0, math.Min(1, severity))
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"math"
+	"testing"
+
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+func findFactor(score *types.RiskScore, name string) *types.RiskFactor {
+	for i := range score.Factors {
+		if score.Factors[i].Name == name {
+			return &score.Factors[i]
+		}
+	}
+	return nil
+}
+
+func TestDefaultRiskWeights(t *testing.T) {
+	var sum float64
+	for _, factor := range riskFactors {
+		weight, ok := DefaultRiskWeights[factor.name]
+		if !ok {
+			t.Errorf("factor %s has no default weight", factor.name)
+		}
+		if factor.name != RiskHoneypot {
+			sum += weight
+		}
+	}
+	if sum != 100 {
+		t.Errorf("got non-honeypot weights summing to %v, want 100", sum)
+	}
+}
+
+func TestScoreRiskHoneypot(t *testing.T) {
+	tests := []struct {
+		name     string
+		honeypot *types.HoneypotResult
+		score    float64
+		evidence string
+	}{
+		{"clean round trip", &types.HoneypotResult{}, 0, "round trip trade succeeded"},
+		{"buy reverted", &types.HoneypotResult{IsHoneypot: true, BuyReverted: true, RevertReason: "TRADING_CLOSED"}, 100, "buy failed in simulation: TRADING_CLOSED"},
+		{"sell reverted", &types.HoneypotResult{IsHoneypot: true, SellReverted: true, RevertReason: "blacklisted"}, 100, "sell failed in simulation: blacklisted"},
+		{"no tokens from buy", &types.HoneypotResult{IsHoneypot: true, BuyTaxPct: 100}, 100, "buy returned no tokens in simulation"},
+		{"sell taxed away", &types.HoneypotResult{IsHoneypot: true, SellTaxPct: 99.5}, 100, "99.50% sell tax in simulation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := ScoreRisk(&types.Token{Honeypot: tt.honeypot}, nil)
+			factor := findFactor(score, RiskHoneypot)
+			if factor == nil || factor.Evidence != tt.evidence {
+				t.Fatalf("got honeypot factor %+v, want evidence %q", factor, tt.evidence)
+			}
+			if score.Score < tt.score || (tt.score == 0 && factor.Contribution != 0) {
+				t.Errorf("got score %v with honeypot contribution %v, want at least %v", score.Score, factor.Contribution, tt.score)
+			}
+		})
+	}
+}
+
+func TestScoreRisk(t *testing.T) {
+	token := &types.Token{
+		Honeypot:   &types.HoneypotResult{SellTaxPct: 25},
+		LPAnalysis: &types.LPAnalysis{BurnedPct: 50},
+		Ownership:  &types.Ownership{Source: "owner()", OwnerKind: types.OwnerKindEOA},
+	}
+
+	score := ScoreRisk(token, nil)
+	want := map[string]float64{
+		RiskSellTax:    6,
+		RiskLPUnlocked: 5,
+		RiskOwnership:  7,
+	}
+	var total float64
+	for name, contribution := range want {
+		factor := findFactor(score, name)
+		if factor == nil || math.Abs(factor.Contribution-contribution) > 1e-9 {
+			t.Errorf("%s: got %+v, want contribution %v", name, factor, contribution)
+		}
+		total += contribution
+	}
+	if math.Abs(score.Score-total) > 1e-9 {
+		t.Errorf("got score %v, want %v", score.Score, total)
+	}
+	if factor := findFactor(score, RiskSnipers); factor == nil || factor.Evidence != "not available" {
+		t.Errorf("got snipers factor %+v, want it reported as not available", factor)
+	}
+	for i := 1; i < len(score.Factors); i++ {
+		if score.Factors[i].Contribution > score.Factors[i-1].Contribution {
+			t.Fatalf("factors not sorted by contribution: %+v", score.Factors)
+		}
+	}
+
+	// A weight of 0 drops the factor and overrides replace the default weight
+	score = ScoreRisk(token, map[string]float64{RiskOwnership: 0, RiskSellTax: 50})
+	if factor := findFactor(score, RiskOwnership); factor != nil {
+		t.Errorf("got ownership factor %+v, want it turned off", factor)
+	}
+	if factor := findFactor(score, RiskSellTax); factor == nil || factor.Contribution != 25 {
+		t.Errorf("got sell tax factor %+v, want contribution 25", factor)
+	}
+
+	// The total never goes past 100
+	score = ScoreRisk(token, map[string]float64{RiskSellTax: 500})
+	if score.Score != 100 {
+		t.Errorf("got score %v, want it capped at 100", score.Score)
+	}
+}
@@ -0,0 +1,222 @@
+package simulation
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	eth_call runs a single message against a fixed state, so a round trip like buy,
+	approve, sell cannot be split over several calls. Instead, an executor contract is
+	injected with a state override and runs a list of steps in one call:
+
+		calldata: for every step  [to (32)][value (32)][data length (32)][data, padded to 32]
+		result:   for every step  [success (32)][executor balance after (32)][return length (32)][return data, padded to 32]
+
+	A failed step doesn't revert the call, so later steps still run and the revert
+	data of the failed step is returned. Calls with empty calldata (plain ETH transfers)
+	are accepted so the executor can receive ETH from the router.
+*/
+
+type Step struct {
+	To    common.Address
+	Value *big.Int
+	Data  []byte
+}
+
+type StepResult struct {
+	Success    bool
+	Balance    *big.Int
+	ReturnData []byte
+}
+
+type Executor interface {
+	// Address is where the executor contract runs, and so the account that
+	// buys, holds and sells tokens during a simulation
+	Address() common.Address
+	Execute(steps []Step) ([]StepResult, error)
+}
+
+var ExecutorCode = buildExecutorCode()
+
+// RPCExecutor runs steps with eth_call against a node that supports state overrides
+type RPCExecutor struct {
+	client      *gethclient.Client
+	address     common.Address
+	funding     *big.Int
+	blockNumber *big.Int
+}
+
+// NewRPCExecutor injects the executor at address, funded with funding wei, and runs
+// it at blockNumber (nil for the latest block)
+func NewRPCExecutor(ethNodeURL string, address common.Address, funding *big.Int, blockNumber *big.Int) (*RPCExecutor, error) {
+	rpcClient, err := rpc.Dial(ethNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err)
+	}
+	return &RPCExecutor{
+		client:      gethclient.New(rpcClient),
+		address:     address,
+		funding:     funding,
+		blockNumber: blockNumber,
+	}, nil
+}
+
+func (e *RPCExecutor) Address() common.Address {
+	return e.address
+}
+
+func (e *RPCExecutor) Execute(steps []Step) ([]StepResult, error) {
+	overrides := map[common.Address]gethclient.OverrideAccount{
+		e.address: {
+			Code:    ExecutorCode,
+			Balance: e.funding,
+		},
+	}
+	msg := ethereum.CallMsg{
+		From: e.address,
+		To:   &e.address,
+		Gas:  30_000_000,
+		Data: EncodeSteps(steps),
+	}
+	output, err := e.client.CallContract(context.Background(), msg, e.blockNumber, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("\neth_call with state overrides failed: %v", err)
+	}
+	return DecodeStepResults(output)
+}
+
+func EncodeSteps(steps []Step) []byte {
+	var calldata []byte
+	for _, step := range steps {
+		value := step.Value
+		if value == nil {
+			value = new(big.Int)
+		}
+		calldata = append(calldata, common.LeftPadBytes(step.To.Bytes(), 32)...)
+		calldata = append(calldata, math.U256Bytes(new(big.Int).Set(value))...)
+		calldata = append(calldata, math.U256Bytes(big.NewInt(int64(len(step.Data))))...)
+		calldata = append(calldata, step.Data...)
+		calldata = append(calldata, make([]byte, padding(len(step.Data)))...)
+	}
+	return calldata
+}
+
+func DecodeStepResults(output []byte) ([]StepResult, error) {
+	var results []StepResult
+	for offset := 0; offset < len(output); {
+		if len(output) < offset+96 {
+			return nil, fmt.Errorf("\nExecutor output truncated at offset %d", offset)
+		}
+		length := new(big.Int).SetBytes(output[offset+64 : offset+96])
+		if !length.IsUint64() || length.Uint64() > uint64(len(output)-offset-96) {
+			return nil, fmt.Errorf("\nExecutor output has invalid return length at offset %d", offset)
+		}
+		size := int(length.Uint64())
+		results = append(results, StepResult{
+			Success:    new(big.Int).SetBytes(output[offset:offset+32]).Sign() != 0,
+			Balance:    new(big.Int).SetBytes(output[offset+32 : offset+64]),
+			ReturnData: common.CopyBytes(output[offset+96 : offset+96+size]),
+		})
+		offset += 96 + size + padding(size)
+	}
+	return results, nil
+}
+
+func padding(size int) int {
+	return (32 - size%32) % 32
+}
+
+// program is a minimal assembler with labels resolved to PUSH2 jump targets
+type program struct {
+	code   []byte
+	labels map[string]int
+	jumps  map[int]string
+}
+
+func (p *program) op(ops ...vm.OpCode) *program {
+	for _, op := range ops {
+		p.code = append(p.code, byte(op))
+	}
+	return p
+}
+
+func (p *program) push(value uint64) *program {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], value)
+	data := common.TrimLeftZeroes(buf[:])
+	if len(data) == 0 {
+		data = []byte{0}
+	}
+	p.code = append(p.code, byte(vm.PUSH1)+byte(len(data)-1))
+	p.code = append(p.code, data...)
+	return p
+}
+
+func (p *program) pushLabel(name string) *program {
+	p.code = append(p.code, byte(vm.PUSH2))
+	p.jumps[len(p.code)] = name
+	p.code = append(p.code, 0, 0)
+	return p
+}
+
+func (p *program) label(name string) *program {
+	p.labels[name] = len(p.code)
+	return p.op(vm.JUMPDEST)
+}
+
+func (p *program) bytes() []byte {
+	for offset, name := range p.jumps {
+		binary.BigEndian.PutUint16(p.code[offset:], uint16(p.labels[name]))
+	}
+	return p.code
+}
+
+// buildExecutorCode assembles the executor. The loop keeps [out, ptr] on the stack,
+// the output offset in memory and the offset of the next step in calldata.
+func buildExecutorCode() []byte {
+	p := &program{labels: make(map[string]int), jumps: make(map[int]string)}
+
+	p.op(vm.CALLDATASIZE, vm.ISZERO).pushLabel("stop").op(vm.JUMPI)
+	p.push(0).push(0)
+
+	p.label("loop")
+	// Stop once ptr reaches the end of calldata
+	p.op(vm.CALLDATASIZE, vm.DUP3, vm.LT, vm.ISZERO).pushLabel("done").op(vm.JUMPI)
+	// len = calldataload(ptr + 64)
+	p.push(64).op(vm.DUP3, vm.ADD, vm.CALLDATALOAD)
+	// Copy the step's data to out + 96, where its return data will later be written
+	p.op(vm.DUP1).push(96).op(vm.DUP5, vm.ADD).push(96).op(vm.DUP5, vm.ADD, vm.CALLDATACOPY)
+	// success = call(gas, calldataload(ptr), calldataload(ptr + 32), out + 96, len, 0, 0)
+	p.push(0).push(0).op(vm.DUP3).push(96).op(vm.DUP6, vm.ADD)
+	p.push(32).op(vm.DUP8, vm.ADD, vm.CALLDATALOAD)
+	p.op(vm.DUP8, vm.CALLDATALOAD, vm.GAS, vm.CALL)
+	// Write success, balance and return data at out
+	p.op(vm.DUP3, vm.MSTORE)
+	p.op(vm.SELFBALANCE).push(32).op(vm.DUP4, vm.ADD, vm.MSTORE)
+	p.op(vm.RETURNDATASIZE).push(64).op(vm.DUP4, vm.ADD, vm.MSTORE)
+	p.op(vm.RETURNDATASIZE).push(0).push(96).op(vm.DUP5, vm.ADD, vm.RETURNDATACOPY)
+	// ptr += 96 + padded len
+	p.push(31).op(vm.ADD).push(31).op(vm.NOT, vm.AND).push(96).op(vm.ADD)
+	p.op(vm.DUP3, vm.ADD, vm.SWAP2, vm.POP)
+	// out += 96 + padded return data size
+	p.op(vm.RETURNDATASIZE).push(31).op(vm.ADD).push(31).op(vm.NOT, vm.AND).push(96).op(vm.ADD, vm.ADD)
+	p.pushLabel("loop").op(vm.JUMP)
+
+	p.label("done")
+	p.push(0).op(vm.RETURN)
+
+	p.label("stop")
+	p.op(vm.STOP)
+
+	return p.bytes()
+}
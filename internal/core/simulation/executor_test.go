@@ -0,0 +1,182 @@
+package simulation
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// encodeResults lays out results the way the executor contract returns them
+func encodeResults(results []StepResult) []byte {
+	var output []byte
+	for _, result := range results {
+		success := big.NewInt(0)
+		if result.Success {
+			success = big.NewInt(1)
+		}
+		output = append(output, math.U256Bytes(success)...)
+		output = append(output, math.U256Bytes(new(big.Int).Set(result.Balance))...)
+		output = append(output, math.U256Bytes(big.NewInt(int64(len(result.ReturnData))))...)
+		output = append(output, result.ReturnData...)
+		output = append(output, make([]byte, padding(len(result.ReturnData)))...)
+	}
+	return output
+}
+
+func checkResults(t *testing.T, got, want []StepResult) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Success != want[i].Success || got[i].Balance.Cmp(want[i].Balance) != 0 || !bytes.Equal(got[i].ReturnData, want[i].ReturnData) {
+			t.Errorf("result %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestEncodeSteps(t *testing.T) {
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	data := bytes.Repeat([]byte{0xab}, 33)
+	calldata := EncodeSteps([]Step{
+		{To: to, Value: big.NewInt(7), Data: data},
+		{To: to},
+	})
+
+	if len(calldata) != 96+64+96 {
+		t.Fatalf("got %d bytes, want %d", len(calldata), 96+64+96)
+	}
+	if common.BytesToAddress(calldata[:32]) != to || new(big.Int).SetBytes(calldata[32:64]).Int64() != 7 || new(big.Int).SetBytes(calldata[64:96]).Int64() != 33 {
+		t.Errorf("got first step header %x", calldata[:96])
+	}
+	if !bytes.Equal(calldata[96:129], data) || !bytes.Equal(calldata[129:160], make([]byte, 31)) {
+		t.Errorf("got first step data %x, want it zero padded to 64 bytes", calldata[96:160])
+	}
+	if second := calldata[160:]; common.BytesToAddress(second[:32]) != to || new(big.Int).SetBytes(second[32:96]).Sign() != 0 {
+		t.Errorf("got second step %x, want zero value and no data", second)
+	}
+}
+
+func TestDecodeStepResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []StepResult
+	}{
+		{"no steps", nil},
+		{"empty return data", []StepResult{{Success: true, Balance: big.NewInt(5), ReturnData: []byte{}}}},
+		{"return data of a full word", []StepResult{{Success: true, Balance: big.NewInt(0), ReturnData: bytes.Repeat([]byte{1}, 32)}}},
+		{"several steps with padding", []StepResult{
+			{Success: false, Balance: big.NewInt(1e18), ReturnData: []byte{0x08, 0xc3, 0x79, 0xa0}},
+			{Success: true, Balance: new(big.Int).Lsh(big.NewInt(1), 255), ReturnData: bytes.Repeat([]byte{2}, 33)},
+			{Success: true, Balance: big.NewInt(3), ReturnData: []byte{}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeStepResults(encodeResults(tt.results))
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkResults(t, got, tt.results)
+		})
+	}
+}
+
+func TestDecodeStepResultsInvalid(t *testing.T) {
+	valid := encodeResults([]StepResult{{Success: true, Balance: big.NewInt(1), ReturnData: []byte{1, 2, 3}}})
+	overlong := common.CopyBytes(valid)
+	overlong[95] = 200
+
+	tests := []struct {
+		name   string
+		output []byte
+	}{
+		{"truncated header", valid[:64]},
+		{"return length past the end", overlong},
+		{"return data cut short", valid[:97]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if results, err := DecodeStepResults(tt.output); err == nil {
+				t.Errorf("got %+v, want an error", results)
+			}
+		})
+	}
+}
+
+// newTestSimulator returns a simulator over an empty in-memory state, with no node behind it
+func newTestSimulator(t *testing.T) *Simulator {
+	t.Helper()
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := *params.AllEthashProtocolChanges
+	config.ShanghaiTime = new(uint64)
+	config.TerminalTotalDifficulty = new(big.Int)
+	config.TerminalTotalDifficultyPassed = true
+	header := &gethtypes.Header{Number: big.NewInt(1), Time: 1}
+	return &Simulator{
+		header:      header,
+		chainConfig: &config,
+		state:       statedb,
+		blockNumber: new(big.Int).Set(header.Number),
+		timestamp:   header.Time,
+		hashes:      map[uint64]common.Hash{},
+	}
+}
+
+// echoCode returns its calldata, and reverts with it instead when the first byte is non-zero
+func echoCode() []byte {
+	p := &program{labels: make(map[string]int), jumps: make(map[int]string)}
+	p.op(vm.CALLDATASIZE).push(0).push(0).op(vm.CALLDATACOPY)
+	p.push(0).op(vm.CALLDATALOAD).push(248).op(vm.SHR).pushLabel("revert").op(vm.JUMPI)
+	p.op(vm.CALLDATASIZE).push(0).op(vm.RETURN)
+	p.label("revert")
+	p.op(vm.CALLDATASIZE).push(0).op(vm.REVERT)
+	return p.bytes()
+}
+
+func TestLocalExecutor(t *testing.T) {
+	simulator := newTestSimulator(t)
+	echo := common.HexToAddress("0x0000000000000000000000000000000000000ec0")
+	simulator.SetCode(echo, echoCode())
+	funding := big.NewInt(1000)
+	executor := NewLocalExecutor(simulator, common.HexToAddress("0x0000000000000000000000000000000000000e0e"), funding)
+
+	long := append([]byte{0}, bytes.Repeat([]byte{0xcd}, 40)...)
+	steps := []Step{
+		{To: echo, Value: big.NewInt(10), Data: []byte("\x00hello")},
+		{To: echo, Value: big.NewInt(20), Data: []byte("\x01boom")},
+		{To: echo, Value: big.NewInt(30)},
+		{To: echo, Data: long},
+	}
+	want := []StepResult{
+		{Success: true, Balance: big.NewInt(990), ReturnData: []byte("\x00hello")},
+		{Success: false, Balance: big.NewInt(990), ReturnData: []byte("\x01boom")},
+		{Success: true, Balance: big.NewInt(960), ReturnData: []byte{}},
+		{Success: true, Balance: big.NewInt(960), ReturnData: long},
+	}
+
+	got, err := executor.Execute(steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkResults(t, got, want)
+
+	// Execute runs isolated, so the simulator's own state is untouched
+	if balance := simulator.GetBalance(executor.Address()); balance.Cmp(funding) != 0 {
+		t.Errorf("got executor balance %s after Execute, want %s", balance, funding)
+	}
+	if balance := simulator.GetBalance(echo); balance.Sign() != 0 {
+		t.Errorf("got echo balance %s after Execute, want 0", balance)
+	}
+}
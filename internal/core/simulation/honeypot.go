@@ -0,0 +1,199 @@
+package simulation
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	The round trip runs in two passes because the sell amount depends on what the buy
+	returned. Both passes start from the same state, so the buy is identical in each:
+
+		1. quote the buy, buy, read the executor's token balance
+		2. buy, approve the router, quote the sell, sell
+
+	Taxes are how far the received amounts fall short of the router's quotes. The
+	fee-on-transfer swap variants are used so taxed tokens don't revert on the router's
+	own output checks.
+*/
+
+// maxBuyAmount caps the simulated buy, which is also kept under 1% of the pair's
+// WETH reserve so price impact doesn't dominate the measured tax
+var maxBuyAmount = big.NewInt(5e16)
+
+var deadline = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+func GetBuyAmount(pair *types.UniswapPair) (*big.Int, error) {
+	var reservesResult []interface{}
+	err := pair.Contract.Call(&bind.CallOpts{}, &reservesResult, "getReserves")
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to call getReserves(): %v", err)
+	}
+	wethReserve := reservesResult[1].(*big.Int)
+	if !pair.TokenIsToken0 {
+		wethReserve = reservesResult[0].(*big.Int)
+	}
+
+	buyAmount := new(big.Int).Div(wethReserve, big.NewInt(100))
+	if buyAmount.Cmp(maxBuyAmount) > 0 {
+		buyAmount = new(big.Int).Set(maxBuyAmount)
+	}
+	return buyAmount, nil
+}
+
+// NewThrowawayAddress returns a fresh random address so simulations never
+// touch an account with existing balances or exemptions
+func NewThrowawayAddress() (common.Address, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return common.Address{}, fmt.Errorf("\nFailed to generate key: %v", err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
+
+func SimulateRoundTrip(executor Executor, pair *types.UniswapPair, buyAmount *big.Int) (*types.HoneypotResult, error) {
+	routerABI, err := abi.JSON(strings.NewReader(utils.UniswapV2RouterABI))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to parse UniswapV2RouterABI: %v", err)
+	}
+	erc20ABI, err := abi.JSON(strings.NewReader(utils.ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to parse ERC20ABI: %v", err)
+	}
+
+	buyPath := []common.Address{utils.WETHAddress, pair.Token}
+	sellPath := []common.Address{pair.Token, utils.WETHAddress}
+
+	quoteBuy, err := routerABI.Pack("getAmountsOut", buyAmount, buyPath)
+	if err != nil {
+		return nil, err
+	}
+	buy, err := routerABI.Pack("swapExactETHForTokensSupportingFeeOnTransferTokens", big.NewInt(0), buyPath, executor.Address(), deadline)
+	if err != nil {
+		return nil, err
+	}
+	balanceOf, err := erc20ABI.Pack("balanceOf", executor.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.HoneypotResult{
+		BuyAmountWETH: utils.ToDecimal(buyAmount, 18),
+	}
+
+	results, err := executor.Execute([]Step{
+		{To: utils.UniswapRouterAddress, Data: quoteBuy},
+		{To: utils.UniswapRouterAddress, Value: buyAmount, Data: buy},
+		{To: pair.Token, Data: balanceOf},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !results[0].Success {
+		return nil, fmt.Errorf("\nRouter failed to quote buy: %s", revertReason(results[0].ReturnData))
+	}
+	if !results[1].Success {
+		result.BuyReverted = true
+		result.IsHoneypot = true
+		result.RevertReason = revertReason(results[1].ReturnData)
+		return result, nil
+	}
+
+	expectedTokens, err := unpackLastAmount(routerABI, results[0].ReturnData)
+	if err != nil {
+		return nil, err
+	}
+	if !results[2].Success {
+		return nil, fmt.Errorf("\nFailed to call balanceOf(): %s", revertReason(results[2].ReturnData))
+	}
+	receivedTokens := new(big.Int).SetBytes(results[2].ReturnData)
+	result.BuyTaxPct = shortfallPct(expectedTokens, receivedTokens)
+	if receivedTokens.Sign() == 0 {
+		result.IsHoneypot = true
+		return result, nil
+	}
+
+	approve, err := erc20ABI.Pack("approve", utils.UniswapRouterAddress, receivedTokens)
+	if err != nil {
+		return nil, err
+	}
+	quoteSell, err := routerABI.Pack("getAmountsOut", receivedTokens, sellPath)
+	if err != nil {
+		return nil, err
+	}
+	sell, err := routerABI.Pack("swapExactTokensForETHSupportingFeeOnTransferTokens", receivedTokens, big.NewInt(0), sellPath, executor.Address(), deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err = executor.Execute([]Step{
+		{To: utils.UniswapRouterAddress, Value: buyAmount, Data: buy},
+		{To: pair.Token, Data: approve},
+		{To: utils.UniswapRouterAddress, Data: quoteSell},
+		{To: utils.UniswapRouterAddress, Data: sell},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !results[1].Success {
+		result.SellReverted = true
+		result.IsHoneypot = true
+		result.RevertReason = "approve: " + revertReason(results[1].ReturnData)
+		return result, nil
+	}
+	if !results[3].Success {
+		result.SellReverted = true
+		result.IsHoneypot = true
+		result.RevertReason = revertReason(results[3].ReturnData)
+		return result, nil
+	}
+
+	expectedWETH, err := unpackLastAmount(routerABI, results[2].ReturnData)
+	if err != nil {
+		return nil, err
+	}
+	receivedWETH := new(big.Int).Sub(results[3].Balance, results[2].Balance)
+	result.SellTaxPct = shortfallPct(expectedWETH, receivedWETH)
+	result.IsHoneypot = result.SellTaxPct >= 99
+
+	return result, nil
+}
+
+func unpackLastAmount(routerABI abi.ABI, data []byte) (*big.Int, error) {
+	unpacked, err := routerABI.Unpack("getAmountsOut", data)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to unpack getAmountsOut(): %v", err)
+	}
+	amounts := unpacked[0].([]*big.Int)
+	return amounts[len(amounts)-1], nil
+}
+
+// shortfallPct is how much smaller actual is than expected, as a percentage of expected
+func shortfallPct(expected, actual *big.Int) float64 {
+	if expected.Sign() == 0 || actual.Cmp(expected) >= 0 {
+		return 0
+	}
+	shortfall := new(big.Float).SetInt(new(big.Int).Sub(expected, actual))
+	pct, _ := shortfall.Quo(shortfall, new(big.Float).SetInt(expected)).Float64()
+	return pct * 100
+}
+
+func revertReason(data []byte) string {
+	if len(data) == 0 {
+		return "reverted without a reason"
+	}
+	reason, err := abi.UnpackRevert(data)
+	if err != nil {
+		return hexutil.Encode(data)
+	}
+	return reason
+}
@@ -0,0 +1,28 @@
+package simulation
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestShortfallPct(t *testing.T) {
+	tests := []struct {
+		name             string
+		expected, actual int64
+		want             float64
+	}{
+		{"exact amount", 1000, 1000, 0},
+		{"nothing received", 1000, 0, 100},
+		{"partial tax", 1000, 900, 10},
+		{"over-delivery", 1000, 1500, 0},
+		{"nothing expected", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shortfallPct(big.NewInt(tt.expected), big.NewInt(tt.actual))
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
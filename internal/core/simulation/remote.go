@@ -0,0 +1,303 @@
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+/*
+	remoteDatabase implements state.Database on top of JSON-RPC. Instead of resolving
+	trie nodes it fetches whole accounts, code and storage slots from the node at a
+	pinned block the first time the StateDB asks for them. Everything fetched is kept
+	in memory and can be saved to cache files, so repeated runs don't refetch: accounts
+	and storage to a file per chain and block, and code, which doesn't depend on the
+	block, to a file per chain that every block shares.
+
+	Writes never reach this layer: the StateDB keeps them in its own dirty state and
+	nothing is ever committed.
+*/
+
+type cachedAccount struct {
+	Nonce    uint64       `json:"nonce"`
+	Balance  *hexutil.Big `json:"balance"`
+	CodeHash common.Hash  `json:"codeHash"`
+}
+
+type remoteCache struct {
+	Accounts map[common.Address]*cachedAccount              `json:"accounts"`
+	Code     map[common.Hash]hexutil.Bytes                  `json:"code"`
+	Storage  map[common.Address]map[common.Hash]common.Hash `json:"storage"`
+}
+
+// stateCache is the block-specific part of remoteCache saved to a state file
+type stateCache struct {
+	Accounts map[common.Address]*cachedAccount              `json:"accounts"`
+	Storage  map[common.Address]map[common.Hash]common.Hash `json:"storage"`
+}
+
+type remoteDatabase struct {
+	client      *rpc.Client
+	blockNumber *big.Int
+	diskdb      ethdb.Database
+	triedb      *trie.Database
+
+	lock       sync.Mutex
+	cache      remoteCache
+	stateDirty bool
+	codeDirty  bool
+}
+
+func newRemoteDatabase(client *rpc.Client, blockNumber *big.Int) *remoteDatabase {
+	diskdb := rawdb.NewMemoryDatabase()
+	return &remoteDatabase{
+		client:      client,
+		blockNumber: blockNumber,
+		diskdb:      diskdb,
+		triedb:      trie.NewDatabase(diskdb),
+		cache: remoteCache{
+			Accounts: make(map[common.Address]*cachedAccount),
+			Code:     make(map[common.Hash]hexutil.Bytes),
+			Storage:  make(map[common.Address]map[common.Hash]common.Hash),
+		},
+	}
+}
+
+func (db *remoteDatabase) load(statePath, codePath string) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	cached := stateCache{Accounts: db.cache.Accounts, Storage: db.cache.Storage}
+	if err := readCacheFile(statePath, &cached); err != nil {
+		return err
+	}
+	return readCacheFile(codePath, &db.cache.Code)
+}
+
+func (db *remoteDatabase) save(statePath, codePath string) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.stateDirty {
+		if err := writeCacheFile(statePath, stateCache{Accounts: db.cache.Accounts, Storage: db.cache.Storage}); err != nil {
+			return err
+		}
+		db.stateDirty = false
+	}
+	if db.codeDirty {
+		if err := writeCacheFile(codePath, db.cache.Code); err != nil {
+			return err
+		}
+		db.codeDirty = false
+	}
+	return nil
+}
+
+func readCacheFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeCacheFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, os.FileMode(0644))
+}
+
+func (db *remoteDatabase) account(address common.Address) (*cachedAccount, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if account, ok := db.cache.Accounts[address]; ok {
+		return account, nil
+	}
+
+	var balance hexutil.Big
+	var nonce hexutil.Uint64
+	var code hexutil.Bytes
+	block := hexutil.EncodeBig(db.blockNumber)
+	batch := []rpc.BatchElem{
+		{Method: "eth_getBalance", Args: []interface{}{address, block}, Result: &balance},
+		{Method: "eth_getTransactionCount", Args: []interface{}{address, block}, Result: &nonce},
+		{Method: "eth_getCode", Args: []interface{}{address, block}, Result: &code},
+	}
+	if err := db.client.BatchCallContext(context.Background(), batch); err != nil {
+		return nil, fmt.Errorf("\nFailed to fetch account %s: %v", address, err)
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("\n%s failed for %s: %v", elem.Method, address, elem.Error)
+		}
+	}
+
+	account := &cachedAccount{
+		Nonce:    uint64(nonce),
+		Balance:  &balance,
+		CodeHash: gethtypes.EmptyCodeHash,
+	}
+	if len(code) > 0 {
+		account.CodeHash = crypto.Keccak256Hash(code)
+		if _, ok := db.cache.Code[account.CodeHash]; !ok {
+			db.cache.Code[account.CodeHash] = code
+			db.codeDirty = true
+		}
+	}
+	db.cache.Accounts[address] = account
+	db.stateDirty = true
+	return account, nil
+}
+
+func (db *remoteDatabase) storage(address common.Address, key common.Hash) (common.Hash, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if value, ok := db.cache.Storage[address][key]; ok {
+		return value, nil
+	}
+
+	var value hexutil.Bytes
+	err := db.client.CallContext(context.Background(), &value, "eth_getStorageAt", address, key, hexutil.EncodeBig(db.blockNumber))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("\nFailed to fetch storage %s of %s: %v", key, address, err)
+	}
+
+	if db.cache.Storage[address] == nil {
+		db.cache.Storage[address] = make(map[common.Hash]common.Hash)
+	}
+	db.cache.Storage[address][key] = common.BytesToHash(value)
+	db.stateDirty = true
+	return common.BytesToHash(value), nil
+}
+
+func (db *remoteDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
+	return &remoteTrie{db: db}, nil
+}
+
+func (db *remoteDatabase) OpenStorageTrie(stateRoot common.Hash, addrHash, root common.Hash) (state.Trie, error) {
+	return &remoteTrie{db: db}, nil
+}
+
+func (db *remoteDatabase) CopyTrie(t state.Trie) state.Trie {
+	return t
+}
+
+func (db *remoteDatabase) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	code, ok := db.cache.Code[codeHash]
+	if !ok {
+		return nil, fmt.Errorf("\nCode %s was not fetched with its account", codeHash)
+	}
+	return code, nil
+}
+
+func (db *remoteDatabase) ContractCodeSize(addrHash, codeHash common.Hash) (int, error) {
+	code, err := db.ContractCode(addrHash, codeHash)
+	return len(code), err
+}
+
+func (db *remoteDatabase) DiskDB() ethdb.KeyValueStore {
+	return db.diskdb
+}
+
+func (db *remoteDatabase) TrieDB() *trie.Database {
+	return db.triedb
+}
+
+// remoteTrie serves both the account trie and every storage trie, since reads
+// are keyed by address rather than by trie path
+type remoteTrie struct {
+	db *remoteDatabase
+}
+
+func (t *remoteTrie) GetKey(key []byte) []byte {
+	return nil
+}
+
+func (t *remoteTrie) GetStorage(address common.Address, key []byte) ([]byte, error) {
+	value, err := t.db.storage(address, common.BytesToHash(key))
+	if err != nil || value == (common.Hash{}) {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
+}
+
+func (t *remoteTrie) GetAccount(address common.Address) (*gethtypes.StateAccount, error) {
+	account, err := t.db.account(address)
+	if err != nil {
+		return nil, err
+	}
+	if account.Nonce == 0 && account.Balance.ToInt().Sign() == 0 && account.CodeHash == gethtypes.EmptyCodeHash {
+		return nil, nil
+	}
+	return &gethtypes.StateAccount{
+		Nonce:    account.Nonce,
+		Balance:  new(big.Int).Set(account.Balance.ToInt()),
+		Root:     gethtypes.EmptyRootHash,
+		CodeHash: account.CodeHash.Bytes(),
+	}, nil
+}
+
+func (t *remoteTrie) UpdateStorage(address common.Address, key, value []byte) error {
+	return nil
+}
+
+func (t *remoteTrie) UpdateAccount(address common.Address, account *gethtypes.StateAccount) error {
+	return nil
+}
+
+func (t *remoteTrie) DeleteStorage(address common.Address, key []byte) error {
+	return nil
+}
+
+func (t *remoteTrie) DeleteAccount(address common.Address) error {
+	return nil
+}
+
+func (t *remoteTrie) Hash() common.Hash {
+	return common.Hash{}
+}
+
+func (t *remoteTrie) Commit(collectLeaf bool) (common.Hash, *trie.NodeSet) {
+	return common.Hash{}, nil
+}
+
+func (t *remoteTrie) NodeIterator(startKey []byte) trie.NodeIterator {
+	return nil
+}
+
+func (t *remoteTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWriter) error {
+	return errors.New("remote state does not support proofs")
+}
+
+var (
+	_ state.Database = (*remoteDatabase)(nil)
+	_ state.Trie     = (*remoteTrie)(nil)
+)
@@ -0,0 +1,360 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+/*
+	Simulator runs calls in an in-process EVM against the state of a pinned block.
+	State is fetched lazily through remoteDatabase, so only the accounts and slots a
+	call actually touches are requested from the node. Unlike eth_call, changes made
+	by a call persist in the simulator, so multi-step scenarios can be run as a
+	sequence of calls and inspected in between.
+
+	Mainnet uses go-ethereum's mainnet fork schedule; any other chain (e.g. a local
+	dev chain) is simulated with every supported fork enabled. Forks newer than the
+	vendored go-ethereum knows about (Cancun onwards) are not simulated. A call that
+	fails after running one of their opcodes returns an UnsupportedOpcodeError, since
+	its failure says nothing about the contract.
+*/
+
+const simulationGasLimit = 30_000_000
+
+// ErrRemoteState is returned when a call couldn't complete because state failed to load
+// from the node, as opposed to the call itself failing
+var ErrRemoteState = errors.New("failed to fetch remote state")
+
+// unsupportedOpcodes are the Cancun opcodes, which the vendored EVM treats as invalid
+var unsupportedOpcodes = map[vm.OpCode]string{
+	0x49: "BLOBHASH",
+	0x4a: "BLOBBASEFEE",
+	0x5c: "TLOAD",
+	0x5d: "TSTORE",
+	0x5e: "MCOPY",
+}
+
+// UnsupportedOpcodeError is returned when a call failed after running an opcode the
+// simulated EVM doesn't support
+type UnsupportedOpcodeError struct {
+	Opcode string
+}
+
+func (e *UnsupportedOpcodeError) Error() string {
+	return fmt.Sprintf("uses %s, which the simulated EVM doesn't support", e.Opcode)
+}
+
+type Simulator struct {
+	rpcClient   *rpc.Client
+	client      *ethclient.Client
+	header      *gethtypes.Header
+	chainConfig *params.ChainConfig
+	remote      *remoteDatabase
+	state       *state.StateDB
+	cacheDir    string
+	chainID     *big.Int
+
+	blockNumber *big.Int
+	timestamp   uint64
+	hashes      map[uint64]common.Hash
+
+	// version changes on every persistent state change, so cached results
+	// of snapshot-isolated calls can be invalidated
+	version uint64
+}
+
+// NewSimulator pins the simulation to blockNumber (nil for the latest block). When
+// cacheDir is set, state fetched from the node is loaded from and saved to it.
+func NewSimulator(ethNodeURL string, blockNumber *big.Int, cacheDir string) (*Simulator, error) {
+	rpcClient, err := rpc.Dial(ethNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err)
+	}
+	return newSimulator(rpcClient, blockNumber, cacheDir)
+}
+
+func newSimulator(rpcClient *rpc.Client, blockNumber *big.Int, cacheDir string) (*Simulator, error) {
+	client := ethclient.NewClient(rpcClient)
+
+	header, err := client.HeaderByNumber(context.Background(), blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get header: %v", err)
+	}
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get chain id: %v", err)
+	}
+
+	chainConfig := params.MainnetChainConfig
+	if chainID.Cmp(params.MainnetChainConfig.ChainID) != 0 {
+		config := *params.AllEthashProtocolChanges
+		config.ChainID = chainID
+		config.ShanghaiTime = new(uint64)
+		config.TerminalTotalDifficulty = new(big.Int)
+		config.TerminalTotalDifficultyPassed = true
+		chainConfig = &config
+	}
+
+	remote := newRemoteDatabase(rpcClient, header.Number)
+	if cacheDir != "" {
+		statePath, codePath := stateCachePath(cacheDir, chainID, header.Number), codeCachePath(cacheDir, chainID)
+		if err := remote.load(statePath, codePath); err != nil {
+			return nil, fmt.Errorf("\nFailed to load state cache %s: %v", statePath, err)
+		}
+	}
+
+	statedb, err := state.New(header.Root, remote, nil)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to create state: %v", err)
+	}
+
+	return &Simulator{
+		rpcClient:   rpcClient,
+		client:      client,
+		header:      header,
+		chainConfig: chainConfig,
+		remote:      remote,
+		state:       statedb,
+		cacheDir:    cacheDir,
+		chainID:     chainID,
+		blockNumber: new(big.Int).Set(header.Number),
+		timestamp:   header.Time,
+		hashes:      map[uint64]common.Hash{header.Number.Uint64(): header.Hash()},
+	}, nil
+}
+
+// SaveCache writes the state fetched so far to the cache directory, if one was given.
+// Code is shared by every block of the chain, but accounts and storage only match the
+// pinned block, so state files of the chain's other blocks are removed.
+func (s *Simulator) SaveCache() error {
+	if s.cacheDir == "" {
+		return nil
+	}
+	statePath := stateCachePath(s.cacheDir, s.chainID, s.header.Number)
+	if err := s.remote.save(statePath, codeCachePath(s.cacheDir, s.chainID)); err != nil {
+		return err
+	}
+
+	stalePaths, err := filepath.Glob(filepath.Join(s.cacheDir, fmt.Sprintf("state-%s-*.json", s.chainID)))
+	if err != nil {
+		return err
+	}
+	for _, path := range stalePaths {
+		if path == statePath {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func stateCachePath(cacheDir string, chainID, blockNumber *big.Int) string {
+	return filepath.Join(cacheDir, fmt.Sprintf("state-%s-%s.json", chainID, blockNumber))
+}
+
+func codeCachePath(cacheDir string, chainID *big.Int) string {
+	return filepath.Join(cacheDir, fmt.Sprintf("code-%s.json", chainID))
+}
+
+func (s *Simulator) BlockNumber() *big.Int {
+	return new(big.Int).Set(s.blockNumber)
+}
+
+// AdvanceBlocks moves later calls n blocks ahead of the pinned block, at 12 seconds per block
+func (s *Simulator) AdvanceBlocks(n uint64) {
+	s.blockNumber.Add(s.blockNumber, new(big.Int).SetUint64(n))
+	s.timestamp += 12 * n
+	s.version++
+}
+
+func (s *Simulator) GetBalance(address common.Address) *big.Int {
+	return s.state.GetBalance(address)
+}
+
+func (s *Simulator) SetBalance(address common.Address, amount *big.Int) {
+	s.state.SetBalance(address, amount)
+	s.version++
+}
+
+func (s *Simulator) GetCode(address common.Address) []byte {
+	return s.state.GetCode(address)
+}
+
+func (s *Simulator) SetCode(address common.Address, code []byte) {
+	s.state.SetCode(address, code)
+	s.version++
+}
+
+func (s *Simulator) GetStorage(address common.Address, key common.Hash) common.Hash {
+	return s.state.GetState(address, key)
+}
+
+func (s *Simulator) SetStorage(address common.Address, key, value common.Hash) {
+	s.state.SetState(address, key, value)
+	s.version++
+}
+
+// Fork returns an independent simulator starting from this one's current state.
+// Forks share the state already fetched from the node.
+func (s *Simulator) Fork() *Simulator {
+	fork := *s
+	fork.state = s.state.Copy()
+	fork.blockNumber = new(big.Int).Set(s.blockNumber)
+	return &fork
+}
+
+// Call executes a message from from to to and keeps its state changes. A reverted
+// call returns its revert data together with vm.ErrExecutionReverted.
+func (s *Simulator) Call(from, to common.Address, value *big.Int, data []byte) ([]byte, error) {
+	ret, err := s.call(s.state, from, to, value, data)
+	s.state.Finalise(true)
+	s.version++
+	return ret, err
+}
+
+// CallIsolated executes a message like eth_call would, on a copy of the current
+// state that is discarded afterwards
+func (s *Simulator) CallIsolated(from, to common.Address, value *big.Int, data []byte) ([]byte, error) {
+	return s.call(s.state.Copy(), from, to, value, data)
+}
+
+func (s *Simulator) call(statedb *state.StateDB, from, to common.Address, value *big.Int, data []byte) ([]byte, error) {
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	random := s.header.MixDigest
+	blockContext := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     s.getHash,
+		Coinbase:    s.header.Coinbase,
+		GasLimit:    simulationGasLimit,
+		BlockNumber: new(big.Int).Set(s.blockNumber),
+		Time:        s.timestamp,
+		Difficulty:  new(big.Int),
+		BaseFee:     new(big.Int),
+		Random:      &random,
+	}
+	txContext := vm.TxContext{
+		Origin:   from,
+		GasPrice: new(big.Int),
+	}
+	tracer := &opcodeTracer{}
+	evm := vm.NewEVM(blockContext, txContext, statedb, s.chainConfig, vm.Config{NoBaseFee: true, Tracer: tracer})
+
+	rules := s.chainConfig.Rules(blockContext.BlockNumber, true, blockContext.Time)
+	statedb.Prepare(rules, from, blockContext.Coinbase, &to, vm.ActivePrecompiles(rules), nil)
+	statedb.SetNonce(from, statedb.GetNonce(from)+1)
+
+	ret, _, err := evm.Call(vm.AccountRef(from), to, data, simulationGasLimit, value)
+	if stateErr := statedb.Error(); stateErr != nil {
+		return nil, fmt.Errorf("\n%w: %v", ErrRemoteState, stateErr)
+	}
+	if err != nil && tracer.unsupported != "" {
+		return ret, &UnsupportedOpcodeError{Opcode: tracer.unsupported}
+	}
+	return ret, err
+}
+
+// opcodeTracer records the first unsupported opcode a call runs, in any call frame
+type opcodeTracer struct {
+	unsupported string
+}
+
+func (t *opcodeTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if name, ok := unsupportedOpcodes[op]; ok && t.unsupported == "" {
+		t.unsupported = name
+	}
+}
+
+func (t *opcodeTracer) CaptureTxStart(gasLimit uint64) {}
+func (t *opcodeTracer) CaptureTxEnd(restGas uint64)    {}
+func (t *opcodeTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+func (t *opcodeTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+func (t *opcodeTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+func (t *opcodeTracer) CaptureExit(output []byte, gasUsed uint64, err error) {}
+func (t *opcodeTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (s *Simulator) getHash(number uint64) common.Hash {
+	if hash, ok := s.hashes[number]; ok {
+		return hash
+	}
+	header, err := s.client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil {
+		// BLOCKHASH of an unknown block is zero, which is also what the EVM returns
+		// for blocks outside the last 256
+		return common.Hash{}
+	}
+	s.hashes[number] = header.Hash()
+	return header.Hash()
+}
+
+// LocalExecutor runs executor steps in the simulator. Each Execute runs isolated on
+// the simulator's current state, matching eth_call semantics, and results are cached
+// until the simulator's state changes.
+type LocalExecutor struct {
+	simulator *Simulator
+	address   common.Address
+	results   map[common.Hash][]StepResult
+	version   uint64
+}
+
+func NewLocalExecutor(simulator *Simulator, address common.Address, funding *big.Int) *LocalExecutor {
+	simulator.SetCode(address, ExecutorCode)
+	simulator.SetBalance(address, funding)
+	return &LocalExecutor{
+		simulator: simulator,
+		address:   address,
+		results:   make(map[common.Hash][]StepResult),
+		version:   simulator.version,
+	}
+}
+
+func (e *LocalExecutor) Address() common.Address {
+	return e.address
+}
+
+func (e *LocalExecutor) Execute(steps []Step) ([]StepResult, error) {
+	if e.version != e.simulator.version {
+		e.results = make(map[common.Hash][]StepResult)
+	}
+
+	calldata := EncodeSteps(steps)
+	key := crypto.Keccak256Hash(calldata)
+	if results, ok := e.results[key]; ok {
+		return results, nil
+	}
+
+	output, err := e.simulator.CallIsolated(e.address, e.address, nil, calldata)
+	if err != nil {
+		return nil, fmt.Errorf("\nExecutor call failed: %v", err)
+	}
+
+	results, err := DecodeStepResults(output)
+	if err != nil {
+		return nil, err
+	}
+	e.results[key] = results
+	e.version = e.simulator.version
+	return results, nil
+}
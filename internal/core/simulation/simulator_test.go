@@ -0,0 +1,102 @@
+package simulation
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+func TestCallUnsupportedOpcode(t *testing.T) {
+	tests := []struct {
+		name string
+		code []byte
+		want string
+	}{
+		{"MCOPY", []byte{byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, 0x5e}, "MCOPY"},
+		{"TSTORE", []byte{byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, 0x5d}, "TSTORE"},
+		{"plain revert", []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			simulator := newTestSimulator(t)
+			target := common.HexToAddress("0x0000000000000000000000000000000000000c0d")
+			simulator.SetCode(target, tt.code)
+
+			_, err := simulator.Call(common.HexToAddress("0x0000000000000000000000000000000000000f00"), target, nil, nil)
+			var unsupported *UnsupportedOpcodeError
+			if errors.As(err, &unsupported) {
+				if unsupported.Opcode != tt.want {
+					t.Errorf("got unsupported opcode %s, want %q", unsupported.Opcode, tt.want)
+				}
+			} else if tt.want != "" || err == nil {
+				t.Errorf("got error %v, want unsupported opcode %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallUnsupportedOpcodeInNestedFrame(t *testing.T) {
+	simulator := newTestSimulator(t)
+	inner := common.HexToAddress("0x000000000000000000000000000000000000001e")
+	outer := common.HexToAddress("0x000000000000000000000000000000000000000f")
+	simulator.SetCode(inner, []byte{byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, 0x5e})
+
+	// Call inner and revert when it fails, like a token calling a library
+	p := &program{labels: make(map[string]int), jumps: make(map[int]string)}
+	p.push(0).push(0).push(0).push(0).push(0).push(0x1e).op(vm.GAS, vm.CALL)
+	p.pushLabel("ok").op(vm.JUMPI)
+	p.push(0).push(0).op(vm.REVERT)
+	p.label("ok")
+	p.op(vm.STOP)
+	simulator.SetCode(outer, p.bytes())
+
+	_, err := simulator.Call(common.HexToAddress("0x0000000000000000000000000000000000000f00"), outer, nil, nil)
+	var unsupported *UnsupportedOpcodeError
+	if !errors.As(err, &unsupported) || unsupported.Opcode != "MCOPY" {
+		t.Errorf("got error %v, want unsupported opcode MCOPY", err)
+	}
+}
+
+func TestSaveCachePrunesOtherBlocks(t *testing.T) {
+	dir := t.TempDir()
+	chainID := big.NewInt(1)
+	stale := stateCachePath(dir, chainID, big.NewInt(99))
+	otherChain := stateCachePath(dir, big.NewInt(10), big.NewInt(99))
+	for _, path := range []string{stale, otherChain} {
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	simulator := newTestSimulator(t)
+	simulator.cacheDir, simulator.chainID = dir, chainID
+	simulator.remote = newRemoteDatabase(nil, simulator.header.Number)
+	code := []byte{byte(vm.STOP)}
+	simulator.remote.cache.Code[common.BytesToHash([]byte{1})] = code
+	simulator.remote.stateDirty, simulator.remote.codeDirty = true, true
+	if err := simulator.SaveCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(stale); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v for the stale state file, want it removed", err)
+	}
+	for _, path := range []string{otherChain, stateCachePath(dir, chainID, simulator.header.Number), codeCachePath(dir, chainID)} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("%s: %v", filepath.Base(path), err)
+		}
+	}
+
+	reloaded := newRemoteDatabase(nil, simulator.header.Number)
+	if err := reloaded.load(stateCachePath(dir, chainID, big.NewInt(100)), codeCachePath(dir, chainID)); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.cache.Code) != 1 {
+		t.Errorf("got %d cached code entries for another block, want the chain's code reused", len(reloaded.cache.Code))
+	}
+}
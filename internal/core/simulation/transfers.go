@@ -0,0 +1,388 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	Transfer restrictions are measured with plain transfers between fresh addresses in
+	the simulator, each test running on its own fork of a state where the senders hold
+	tokens:
+
+		- fee:         a small transfer, comparing what the recipient received to what was sent
+		- max size:    a binary search for the largest transfer that succeeds
+		- max wallet:  when the size is capped, two transfers of 60% of the cap from different
+		               senders to the same recipient, failing only if the recipient's balance is capped
+		- cooldown:    two transfers from the same sender in one block, then advancing
+		               blocks until the second one succeeds
+
+	Senders are funded with the whole supply by locating the token's balance mapping and
+	writing their balance directly, so any limit below the supply can be found. Tokens with
+	non-standard balance storage (e.g. reflection tokens) are bought through the router
+	instead, which caps the size search at what the buy returns. SearchLimitPct records
+	how much of the supply the search could reach.
+
+	A token whose transfers fail on an opcode the simulator doesn't support is reported
+	as not simulated rather than as restricted.
+*/
+
+// MaxCooldownBlocks is how many blocks are advanced looking for the end of a cooldown
+const MaxCooldownBlocks = 20
+
+const (
+	maxBalanceSlot       = 100
+	maxSearchIterations  = 256
+	searchPrecisionRatio = 1000
+)
+
+type transferTester struct {
+	base         *Simulator
+	token        common.Address
+	decimals     uint8
+	erc20ABI     abi.ABI
+	restrictions *types.TransferRestrictions
+}
+
+func AnalyzeTransferRestrictions(simulator *Simulator, tokenAddress common.Address, decimals uint8) (*types.TransferRestrictions, error) {
+	erc20ABI, err := abi.JSON(strings.NewReader(utils.ERC20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to parse ERC20ABI: %v", err)
+	}
+	t := &transferTester{
+		base:         simulator.Fork(),
+		token:        tokenAddress,
+		decimals:     decimals,
+		erc20ABI:     erc20ABI,
+		restrictions: &types.TransferRestrictions{},
+	}
+
+	restrictions, err := t.analyze()
+	var unsupported *UnsupportedOpcodeError
+	if errors.As(err, &unsupported) {
+		return &types.TransferRestrictions{NotSimulated: unsupported.Error()}, nil
+	}
+	return restrictions, err
+}
+
+func (t *transferTester) analyze() (*types.TransferRestrictions, error) {
+	var err error
+	senders := make([]common.Address, 2)
+	for i := range senders {
+		senders[i], err = NewThrowawayAddress()
+		if err != nil {
+			return nil, err
+		}
+	}
+	balance, err := t.fund(senders)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Sign() == 0 {
+		// Nothing to measure when neither funding method works
+		return nil, nil
+	}
+
+	if err := t.measureFee(senders[0], balance); err != nil {
+		return nil, err
+	}
+	if err := t.measureMaxTransfer(senders, balance); err != nil {
+		return nil, err
+	}
+	if err := t.detectCooldown(senders[0], balance); err != nil {
+		return nil, err
+	}
+
+	return t.restrictions, nil
+}
+
+// fund gives every sender the same token balance in the base state and returns it
+func (t *transferTester) fund(senders []common.Address) (*big.Int, error) {
+	totalSupply, err := t.call(t.base, senders[0], "totalSupply")
+	if err != nil {
+		return nil, err
+	}
+
+	slotKey, err := t.findBalanceSlot(senders[0], totalSupply)
+	if err != nil {
+		return nil, err
+	}
+	if slotKey != nil {
+		for _, sender := range senders {
+			t.base.SetStorage(t.token, slotKey(sender), common.BigToHash(totalSupply))
+		}
+		t.restrictions.FundingMethod = "storage"
+		t.restrictions.SearchLimitPct = 100
+		return totalSupply, nil
+	}
+
+	routerABI, err := abi.JSON(strings.NewReader(utils.UniswapV2RouterABI))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to parse UniswapV2RouterABI: %v", err)
+	}
+	path := []common.Address{utils.WETHAddress, t.token}
+	var balance *big.Int
+	for _, sender := range senders {
+		buy, err := routerABI.Pack("swapExactETHForTokensSupportingFeeOnTransferTokens", big.NewInt(0), path, sender, deadline)
+		if err != nil {
+			return nil, err
+		}
+		t.base.SetBalance(sender, new(big.Int).Mul(maxBuyAmount, big.NewInt(2)))
+		if _, err := t.base.Call(sender, utils.UniswapRouterAddress, maxBuyAmount, buy); abortsAnalysis(err) {
+			return nil, err
+		}
+		// Tests assume each sender holds at least the returned balance
+		received, err := t.call(t.base, sender, "balanceOf", sender)
+		if err != nil {
+			return nil, err
+		}
+		if balance == nil || received.Cmp(balance) < 0 {
+			balance = received
+		}
+	}
+	t.restrictions.FundingMethod = "buy"
+	if totalSupply.Sign() > 0 {
+		limitPct, _ := new(big.Float).Quo(new(big.Float).SetInt(balance), new(big.Float).SetInt(totalSupply)).Float64()
+		t.restrictions.SearchLimitPct = limitPct * 100
+	}
+	return balance, nil
+}
+
+// findBalanceSlot probes the first storage slots for a balances mapping, in both the
+// Solidity and Vyper key layouts, returning a function deriving a holder's key
+func (t *transferTester) findBalanceSlot(holder common.Address, amount *big.Int) (func(common.Address) common.Hash, error) {
+	for slot := int64(0); slot < maxBalanceSlot; slot++ {
+		slotBytes := common.BigToHash(big.NewInt(slot)).Bytes()
+		layouts := []func(common.Address) common.Hash{
+			func(address common.Address) common.Hash {
+				return crypto.Keccak256Hash(common.LeftPadBytes(address.Bytes(), 32), slotBytes)
+			},
+			func(address common.Address) common.Hash {
+				return crypto.Keccak256Hash(slotBytes, common.LeftPadBytes(address.Bytes(), 32))
+			},
+		}
+		for _, layout := range layouts {
+			fork := t.base.Fork()
+			fork.SetStorage(t.token, layout(holder), common.BigToHash(amount))
+			balance, err := t.call(fork, holder, "balanceOf", holder)
+			if abortsAnalysis(err) {
+				return nil, err
+			} else if err == nil && balance.Cmp(amount) == 0 {
+				return layout, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (t *transferTester) measureFee(sender common.Address, balance *big.Int) error {
+	amount := new(big.Int).Div(balance, big.NewInt(1000))
+	if amount.Sign() == 0 {
+		amount = balance
+	}
+	recipient, err := NewThrowawayAddress()
+	if err != nil {
+		return err
+	}
+
+	fork := t.base.Fork()
+	ok, err := t.transfer(fork, sender, recipient, amount, "fee measurement transfer", true)
+	if err != nil || !ok {
+		return err
+	}
+	received, err := t.call(fork, recipient, "balanceOf", recipient)
+	if err != nil {
+		return err
+	}
+	t.restrictions.TransferFeePct = shortfallPct(amount, received)
+	return nil
+}
+
+func (t *transferTester) measureMaxTransfer(senders []common.Address, balance *big.Int) error {
+	maxTransfer, err := t.searchLargest(balance, func(amount *big.Int, record bool) (bool, error) {
+		recipient, err := NewThrowawayAddress()
+		if err != nil {
+			return false, err
+		}
+		description := fmt.Sprintf("transfer of %s tokens", utils.ToDecimal(amount, t.decimals).Text('f', 4))
+		return t.transfer(t.base.Fork(), senders[0], recipient, amount, description, record)
+	})
+	if err != nil || maxTransfer.Cmp(balance) == 0 {
+		return err
+	}
+
+	// A capped transfer size is either a max transaction or a max wallet limit. Two
+	// transfers under the cap into one wallet only fail if the wallet is capped.
+	recipient, err := NewThrowawayAddress()
+	if err != nil {
+		return err
+	}
+	first := new(big.Int).Div(new(big.Int).Mul(maxTransfer, big.NewInt(6)), big.NewInt(10))
+	walletBase := t.base.Fork()
+	ok, err := t.transfer(walletBase, senders[0], recipient, first, "first transfer into one wallet", true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		t.restrictions.MaxTransferAmount = utils.ToDecimal(maxTransfer, t.decimals)
+		return nil
+	}
+
+	second, err := t.searchLargest(first, func(amount *big.Int, record bool) (bool, error) {
+		return t.transfer(walletBase.Fork(), senders[1], recipient, amount, "second transfer into one wallet", record)
+	})
+	if err != nil {
+		return err
+	}
+	if second.Cmp(first) == 0 {
+		t.restrictions.MaxTransferAmount = utils.ToDecimal(maxTransfer, t.decimals)
+	} else {
+		t.restrictions.MaxWalletAmount = utils.ToDecimal(new(big.Int).Add(first, second), t.decimals)
+	}
+	return nil
+}
+
+// searchLargest binary searches for the largest amount up to limit that succeeds,
+// recording the boundary attempts as evidence
+func (t *transferTester) searchLargest(limit *big.Int, try func(amount *big.Int, record bool) (bool, error)) (*big.Int, error) {
+	ok, err := try(limit, false)
+	if err != nil {
+		return nil, err
+	} else if ok {
+		return limit, nil
+	}
+
+	lo, hi := new(big.Int), new(big.Int).Set(limit)
+	precision := new(big.Int).Div(limit, big.NewInt(searchPrecisionRatio))
+	for i := 0; i < maxSearchIterations && new(big.Int).Sub(hi, lo).Cmp(precision) > 0; i++ {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		ok, err := try(mid, false)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	if lo.Sign() > 0 {
+		if _, err := try(lo, true); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := try(hi, true); err != nil {
+		return nil, err
+	}
+	return lo, nil
+}
+
+func (t *transferTester) detectCooldown(sender common.Address, balance *big.Int) error {
+	amount := new(big.Int).Div(balance, big.NewInt(1000))
+	if amount.Sign() == 0 {
+		amount = balance
+	}
+	recipients := make([]common.Address, 2)
+	for i := range recipients {
+		var err error
+		if recipients[i], err = NewThrowawayAddress(); err != nil {
+			return err
+		}
+	}
+
+	fork := t.base.Fork()
+	ok, err := t.transfer(fork, sender, recipients[0], amount, "first transfer in block", false)
+	if err != nil || !ok {
+		return err
+	}
+	ok, err = t.transfer(fork.Fork(), sender, recipients[1], amount, "second transfer in the same block", true)
+	if err != nil || ok {
+		return err
+	}
+
+	t.restrictions.HasCooldown = true
+	for blocks := uint64(1); blocks <= MaxCooldownBlocks; blocks++ {
+		later := fork.Fork()
+		later.AdvanceBlocks(blocks)
+		description := fmt.Sprintf("second transfer %d blocks later", blocks)
+		ok, err := t.transfer(later, sender, recipients[1], amount, description, false)
+		if err != nil {
+			return err
+		}
+		if ok {
+			// Run it again on a clean fork to record it as evidence
+			later = fork.Fork()
+			later.AdvanceBlocks(blocks)
+			_, err = t.transfer(later, sender, recipients[1], amount, description, true)
+			t.restrictions.CooldownBlocks = blocks
+			return err
+		}
+	}
+	return nil
+}
+
+// transfer runs transfer(to, amount) from sender, treating a revert or a false return as failure
+func (t *transferTester) transfer(simulator *Simulator, sender, recipient common.Address, amount *big.Int, description string, record bool) (bool, error) {
+	data, err := t.erc20ABI.Pack("transfer", recipient, amount)
+	if err != nil {
+		return false, err
+	}
+
+	tx := &types.SimulatedTx{
+		BlockNumber: simulator.BlockNumber().Uint64(),
+		From:        sender,
+		To:          t.token,
+		Input:       hexutil.Encode(data),
+		Description: description,
+	}
+	ret, err := simulator.Call(sender, t.token, nil, data)
+	if abortsAnalysis(err) {
+		return false, err
+	}
+	tx.Success = err == nil && (len(ret) == 0 || new(big.Int).SetBytes(ret).Sign() != 0)
+	if err != nil {
+		tx.RevertReason = revertReason(ret)
+	} else if !tx.Success {
+		tx.RevertReason = "returned false"
+	}
+
+	if record {
+		t.restrictions.Evidence = append(t.restrictions.Evidence, tx)
+	}
+	return tx.Success, nil
+}
+
+// abortsAnalysis reports whether a failed call says nothing about the token, because
+// state couldn't be fetched or the EVM couldn't run it
+func abortsAnalysis(err error) bool {
+	var unsupported *UnsupportedOpcodeError
+	return errors.Is(err, ErrRemoteState) || errors.As(err, &unsupported)
+}
+
+// call runs a view function returning a single uint256
+func (t *transferTester) call(simulator *Simulator, from common.Address, method string, args ...interface{}) (*big.Int, error) {
+	data, err := t.erc20ABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := simulator.CallIsolated(from, t.token, nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("\n%s() failed: %w", method, err)
+	}
+	unpacked, err := t.erc20ABI.Unpack(method, ret)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to unpack %s(): %v", method, err)
+	}
+	return unpacked[0].(*big.Int), nil
+}
@@ -0,0 +1,297 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/labels"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	Buys in the launch block (the first liquidity add) and the following blocks are
+	grouped by the address receiving the tokens, and flagged as:
+
+		- same-block:        bought in the launch block itself
+		- bundled:           in the launch block, directly after the liquidity add with
+		                     only other buys in between
+		- high-priority-fee: paid a tip of at least highPriorityFeeFactor times the block's median tip
+		- deployer-funded:   the transaction sender was first funded by the deployer, or is the deployer
+		- mev-bot:           the sender, the called contract or the recipient is labelled mev
+
+	Any flag makes the buyer a suspected sniper, and the tokens they bought count towards
+	the sniped share of the total supply.
+*/
+
+const (
+	SniperFlagSameBlock      = "same-block"
+	SniperFlagBundled        = "bundled"
+	SniperFlagHighPriority   = "high-priority-fee"
+	SniperFlagDeployerFunded = "deployer-funded"
+	SniperFlagMEVBot         = "mev-bot"
+
+	highPriorityFeeFactor = 5
+)
+
+type blockFees struct {
+	block     *gethtypes.Block
+	medianTip *big.Int
+	txIndex   map[common.Hash]int
+}
+
+func GenerateSniperReport(ethNodeURL string, tokenAddress common.Address, blocks uint64, addressLabels labels.Labels) (*types.SniperReport, error) {
+	cl, err := ethclient.Dial(ethNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
+	}
+
+	pair, err := dexes.GetUniswapPair(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetUniswapPair() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	} else if pair == nil {
+		return nil, fmt.Errorf("\nNo Uniswap V2 WETH pair found:\n\tToken Address: %s", tokenAddress)
+	}
+	creation, err := contracts.GetContractCreator(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetContractCreator() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+
+	return AnalyzeSnipers(cl, pair, creation.Creator, blocks, addressLabels)
+}
+
+func AnalyzeSnipers(cl *ethclient.Client, pair *types.UniswapPair, deployer common.Address, blocks uint64, addressLabels labels.Labels) (*types.SniperReport, error) {
+	report := &types.SniperReport{
+		PairAddress: pair.Address,
+		Blocks:      blocks,
+	}
+
+	timeline, err := GetLiquidityTimeline(cl, pair)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range timeline.Events {
+		if event.Add {
+			report.LaunchBlock, report.LaunchTxHash = event.BlockNumber, event.TxHash
+			break
+		}
+	}
+	if report.LaunchTxHash == (common.Hash{}) {
+		return report, nil
+	}
+
+	swaps, err := dexes.GetUniswapSwaps(cl, pair, report.LaunchBlock, report.LaunchBlock+blocks)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetUniswapSwaps() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+	totalSupply, err := getRawTotalSupply(cl, pair.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	fees := make(map[uint64]*blockFees)
+	getFees := func(blockNumber uint64) (*blockFees, error) {
+		if f, ok := fees[blockNumber]; ok {
+			return f, nil
+		}
+		f, err := getBlockFees(cl, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		fees[blockNumber] = f
+		return f, nil
+	}
+
+	launch, err := getFees(report.LaunchBlock)
+	if err != nil {
+		return nil, err
+	}
+	launchIndex, ok := launch.txIndex[report.LaunchTxHash]
+	if !ok {
+		return nil, fmt.Errorf("\nLaunch transaction %s not found in block %d", report.LaunchTxHash, report.LaunchBlock)
+	}
+
+	// Buys right after the liquidity add in the launch block, with nothing else in between, are bundled with it
+	buyIndexes := make(map[int]bool)
+	for _, swap := range swaps {
+		if swap.Buy && swap.BlockNumber == report.LaunchBlock {
+			buyIndexes[launch.txIndex[swap.TxHash]] = true
+		}
+	}
+	bundled := make(map[int]bool)
+	for i := launchIndex + 1; buyIndexes[i]; i++ {
+		bundled[i] = true
+	}
+
+	fundedByDeployer := make(map[common.Address]bool)
+	buyers := make(map[common.Address]*types.EarlyBuyer)
+	var order []common.Address
+	for _, swap := range swaps {
+		if !swap.Buy {
+			continue
+		}
+		f, err := getFees(swap.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		tx := f.block.Transaction(swap.TxHash)
+		if tx == nil {
+			return nil, fmt.Errorf("\nTransaction %s not found in block %d", swap.TxHash, swap.BlockNumber)
+		}
+		sender, err := gethtypes.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to get the signer: %v", err)
+		}
+
+		buyer, ok := buyers[swap.Recipient]
+		if !ok {
+			buyer = &types.EarlyBuyer{
+				Address:           swap.Recipient,
+				Sender:            sender,
+				FirstBlock:        swap.BlockNumber,
+				BlocksAfterLaunch: swap.BlockNumber - report.LaunchBlock,
+				TokenAmount:       new(big.Float),
+			}
+			buyers[swap.Recipient] = buyer
+			order = append(order, swap.Recipient)
+		}
+		buyer.Buys++
+		buyer.TokenAmount.Add(buyer.TokenAmount, swap.TokenAmount)
+
+		if swap.BlockNumber == report.LaunchBlock {
+			addFlag(buyer, SniperFlagSameBlock)
+			if bundled[f.txIndex[swap.TxHash]] {
+				addFlag(buyer, SniperFlagBundled)
+			}
+		}
+
+		tip := tx.EffectiveGasTipValue(f.block.BaseFee())
+		tipGwei, _ := new(big.Float).Quo(new(big.Float).SetInt(tip), big.NewFloat(1e9)).Float64()
+		if tipGwei > buyer.MaxPriorityFeeGwei {
+			buyer.MaxPriorityFeeGwei = tipGwei
+		}
+		if tip.Sign() > 0 && tip.Cmp(new(big.Int).Mul(f.medianTip, big.NewInt(highPriorityFeeFactor))) >= 0 {
+			addFlag(buyer, SniperFlagHighPriority)
+		}
+
+		funded, ok := fundedByDeployer[sender]
+		if !ok {
+			funded = sender == deployer
+			if !funded {
+				funding, err := findFirstFunding(cl, sender, swap.BlockNumber)
+				if err != nil {
+					return nil, fmt.Errorf("\nfindFirstFunding() failed:\n\tAddress: %s\n\tError: %v", sender, err)
+				}
+				funded = funding != nil && funding.Funder == deployer
+			}
+			fundedByDeployer[sender] = funded
+		}
+		if funded {
+			addFlag(buyer, SniperFlagDeployerFunded)
+		}
+
+		checked := []common.Address{sender, swap.Sender, swap.Recipient}
+		if tx.To() != nil {
+			checked = append(checked, *tx.To())
+		}
+		for _, address := range checked {
+			if label, ok := addressLabels.Lookup(address); ok && label.Category == labels.CategoryMEV {
+				addFlag(buyer, SniperFlagMEVBot)
+			}
+		}
+	}
+
+	totalDecimal := utils.ToDecimal(totalSupply, pair.TokenDecimals)
+	sniped := new(big.Float)
+	report.EarlyBuyers = len(order)
+	for _, address := range order {
+		buyer := buyers[address]
+		if totalDecimal.Sign() > 0 {
+			buyer.SupplyPct, _ = new(big.Float).Quo(buyer.TokenAmount, totalDecimal).Float64()
+			buyer.SupplyPct *= 100
+		}
+		if len(buyer.Flags) > 0 {
+			report.Snipers = append(report.Snipers, buyer)
+			sniped.Add(sniped, buyer.TokenAmount)
+		}
+	}
+	if totalDecimal.Sign() > 0 {
+		report.SnipedPct, _ = new(big.Float).Quo(sniped, totalDecimal).Float64()
+		report.SnipedPct *= 100
+	}
+	sort.SliceStable(report.Snipers, func(i, j int) bool {
+		return report.Snipers[i].TokenAmount.Cmp(report.Snipers[j].TokenAmount) > 0
+	})
+
+	return report, nil
+}
+
+func getBlockFees(cl *ethclient.Client, blockNumber uint64) (*blockFees, error) {
+	block, err := cl.BlockByNumber(context.Background(), new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get block %d: %v", blockNumber, err)
+	}
+
+	f := &blockFees{
+		block:     block,
+		medianTip: new(big.Int),
+		txIndex:   make(map[common.Hash]int),
+	}
+	var tips []*big.Int
+	for i, tx := range block.Transactions() {
+		f.txIndex[tx.Hash()] = i
+		tips = append(tips, tx.EffectiveGasTipValue(block.BaseFee()))
+	}
+	if len(tips) > 0 {
+		sort.Slice(tips, func(i, j int) bool {
+			return tips[i].Cmp(tips[j]) < 0
+		})
+		f.medianTip = tips[len(tips)/2]
+	}
+	return f, nil
+}
+
+func addFlag(buyer *types.EarlyBuyer, flag string) {
+	for _, existing := range buyer.Flags {
+		if existing == flag {
+			return
+		}
+	}
+	buyer.Flags = append(buyer.Flags, flag)
+}
+
+func PrintSniperReport(report *types.SniperReport) {
+	fmt.Printf("\nPair Address:             %s\n", report.PairAddress)
+	if report.LaunchTxHash == (common.Hash{}) {
+		fmt.Println("No liquidity has been added")
+		return
+	}
+	fmt.Printf("Launch Block:             %d\n", report.LaunchBlock)
+	fmt.Printf("Launch Tx:                %s\n", report.LaunchTxHash)
+	fmt.Printf("Early Buyers:             %d in %d blocks\n", report.EarlyBuyers, report.Blocks+1)
+	fmt.Printf("Suspected Snipers:        %d\n", len(report.Snipers))
+	fmt.Printf("Sniped Supply:            %.2f%%\n", report.SnipedPct)
+
+	if len(report.Snipers) == 0 {
+		return
+	}
+	fmt.Printf("\n%-42s %-42s %-6s %9s %10s  %s\n", "Buyer", "Sender", "Block", "Supply", "Tip (gwei)", "Flags")
+	for _, buyer := range report.Snipers {
+		fmt.Printf("%-42s %-42s +%-5d %8.2f%% %10.2f  %s\n",
+			buyer.Address.Hex(),
+			buyer.Sender.Hex(),
+			buyer.BlocksAfterLaunch,
+			buyer.SupplyPct,
+			buyer.MaxPriorityFeeGwei,
+			strings.Join(buyer.Flags, ", "),
+		)
+	}
+}
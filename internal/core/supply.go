@@ -0,0 +1,89 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	Circulating supply is the total supply minus the balances of:
+
+		- the zero and dead addresses
+		- the LP lockers and supply_exclusions in the config (lock and vesting contracts)
+		- the token contract itself
+		- the deployer, when exclude_deployer_supply is set
+
+	Market cap prices the circulating supply and fully diluted value the total supply,
+	both at the Uniswap WETH price converted to USD.
+*/
+
+func GetSupplyData(cl *ethclient.Client, conf config.Config, token *types.Token, ethPriceInUSD *big.Float) error {
+	excluded := map[common.Address]string{
+		{}:                "zero address",
+		utils.DeadAddress: "dead address",
+		token.Address:     "token contract",
+	}
+	for _, locker := range conf.LPLockers {
+		excluded[common.HexToAddress(locker.Address)] = locker.Name
+	}
+	for _, exclusion := range conf.SupplyExclusions {
+		excluded[common.HexToAddress(exclusion.Address)] = exclusion.Name
+	}
+	if conf.ExcludeDeployerSupply && token.ContractCreator != (common.Address{}) {
+		excluded[token.ContractCreator] = "deployer"
+	}
+
+	totalSupply, err := getRawTotalSupply(cl, token.Address)
+	if err != nil {
+		return err
+	}
+	tokenContract, err := contracts.NewERC20(cl, token.Address)
+	if err != nil {
+		return err
+	}
+
+	circulating := new(big.Int).Set(totalSupply)
+	token.SupplyExclusions = nil
+	for address, label := range excluded {
+		var result []interface{}
+		err := tokenContract.Contract.Call(&bind.CallOpts{}, &result, "balanceOf", address)
+		if err != nil {
+			return fmt.Errorf("\nFailed to call balanceOf(%s): %v", address, err)
+		}
+		balance := result[0].(*big.Int)
+		if balance.Sign() == 0 {
+			continue
+		}
+		circulating.Sub(circulating, balance)
+		token.SupplyExclusions = append(token.SupplyExclusions, types.SupplyExclusion{
+			Address: address,
+			Label:   label,
+			Balance: balance,
+			Share:   sharePct(balance, totalSupply),
+		})
+	}
+	// Balances can exceed the reported supply for tokens with broken accounting
+	if circulating.Sign() < 0 {
+		circulating.SetInt64(0)
+	}
+	sort.Slice(token.SupplyExclusions, func(i, j int) bool {
+		return token.SupplyExclusions[i].Balance.Cmp(token.SupplyExclusions[j].Balance) > 0
+	})
+
+	token.CirculatingSupply = utils.CalculateTotalSupply(circulating, token.Decimals)
+	if token.UniswapPriceInWETH != nil && ethPriceInUSD != nil {
+		priceInUSD := new(big.Float).Mul(token.UniswapPriceInWETH, ethPriceInUSD)
+		token.MarketCap = new(big.Float).Mul(utils.ToDecimal(circulating, token.Decimals), priceInUSD)
+		token.FullyDilutedValue = new(big.Float).Mul(utils.ToDecimal(totalSupply, token.Decimals), priceInUSD)
+	}
+	return nil
+}
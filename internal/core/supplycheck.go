@@ -0,0 +1,203 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	An ERC20's supply should only change through Transfers from or to the zero address,
+	and its balances only through Transfers. The supply check tests both:
+
+		hidden_mint      totalSupply() above the supply the mint and burn events add up
+				 to at a sampled block, or a holder that sent more than the events
+				 ever gave it
+		hidden_burn      totalSupply() below the supply the events add up to
+		balance_drift    balanceOf() of the largest holders differs from their balance
+				 replayed from Transfers, as with rebasing or reflection tokens
+
+	totalSupply() is sampled at supplySamples blocks from the creation block to the last
+	indexed block, and balanceOf() is read at the last indexed block. Nodes without
+	archive state can't answer for old blocks, and calls they fail are counted as skipped
+	rather than failing the check.
+*/
+
+const (
+	SupplyFlagHiddenMint   = "hidden_mint"
+	SupplyFlagHiddenBurn   = "hidden_burn"
+	SupplyFlagBalanceDrift = "balance_drift"
+
+	supplySamples = 6
+	// balanceCheckHolders is how many of the largest holders have balanceOf() compared
+	balanceCheckHolders = 20
+)
+
+type supplyEvent struct {
+	BlockNumber uint64
+	Timestamp   time.Time
+	TxHash      common.Hash
+	Mint        bool
+	Account     common.Address
+	Value       *big.Int
+}
+
+// CheckSupplyConsistency compares the token's reported supply and balances with the
+// holder index built from its Transfer events
+func CheckSupplyConsistency(cl *ethclient.Client, index *types.HolderIndex, creationBlock uint64) (*types.SupplyConsistency, error) {
+	check := &types.SupplyConsistency{}
+	flags := make(map[string]bool)
+
+	events, err := getSupplyEvents(cl, index.Token, creationBlock, index.LastBlock)
+	if err != nil {
+		return nil, err
+	}
+	tokenContract, err := contracts.NewERC20(cl, index.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, block := range sampleBlocks(creationBlock, index.LastBlock, supplySamples) {
+		totalSupply, err := tokenContract.TotalSupply(&bind.CallOpts{BlockNumber: new(big.Int).SetUint64(block)})
+		if err != nil {
+			check.SamplesSkipped++
+			continue
+		}
+		eventSupply := new(big.Int)
+		for _, event := range events {
+			if event.BlockNumber > block {
+				break
+			}
+			if event.Mint {
+				eventSupply.Add(eventSupply, event.Value)
+			} else {
+				eventSupply.Sub(eventSupply, event.Value)
+			}
+		}
+		difference := new(big.Int).Sub(totalSupply, eventSupply)
+		check.Samples = append(check.Samples, types.SupplySample{
+			BlockNumber: block,
+			TotalSupply: totalSupply,
+			EventSupply: eventSupply,
+			Difference:  difference,
+		})
+		switch difference.Sign() {
+		case 1:
+			flags[SupplyFlagHiddenMint] = true
+		case -1:
+			flags[SupplyFlagHiddenBurn] = true
+		}
+	}
+
+	// A balance below zero means tokens left an address without ever arriving there
+	for _, balance := range index.Balances {
+		if balance.Sign() < 0 {
+			check.NegativeBalances++
+		}
+	}
+	if check.NegativeBalances > 0 {
+		flags[SupplyFlagHiddenMint] = true
+	}
+
+	indexedSum, actualSum := new(big.Int), new(big.Int)
+	opts := &bind.CallOpts{BlockNumber: new(big.Int).SetUint64(index.LastBlock)}
+	for _, holder := range GetLargestHolders(index, nil, balanceCheckHolders) {
+		actual, err := tokenContract.BalanceOf(opts, holder.Address)
+		if err != nil {
+			check.BalancesSkipped++
+			continue
+		}
+		indexedSum.Add(indexedSum, holder.Balance)
+		actualSum.Add(actualSum, actual)
+		if actual.Cmp(holder.Balance) != 0 {
+			check.BalanceMismatches = append(check.BalanceMismatches, types.BalanceMismatch{
+				Address: holder.Address,
+				Indexed: holder.Balance,
+				Actual:  actual,
+			})
+		}
+	}
+	if len(check.BalanceMismatches) > 0 {
+		flags[SupplyFlagBalanceDrift] = true
+		check.BalanceDriftPct = sharePct(new(big.Int).Sub(actualSum, indexedSum), indexedSum)
+	}
+
+	for _, flag := range []string{SupplyFlagHiddenMint, SupplyFlagHiddenBurn, SupplyFlagBalanceDrift} {
+		if flags[flag] {
+			check.Flags = append(check.Flags, flag)
+		}
+	}
+	return check, nil
+}
+
+// sampleBlocks spreads n blocks evenly from first to last, both included
+func sampleBlocks(first, last uint64, n int) []uint64 {
+	if last <= first || n < 2 {
+		return []uint64{last}
+	}
+	var blocks []uint64
+	step := (last - first) / uint64(n-1)
+	for i := 0; i < n-1; i++ {
+		block := first + uint64(i)*step
+		if len(blocks) == 0 || blocks[len(blocks)-1] != block {
+			blocks = append(blocks, block)
+		}
+	}
+	return append(blocks, last)
+}
+
+// getSupplyEvents returns the token's mints and burns in chain order
+func getSupplyEvents(cl *ethclient.Client, tokenAddress common.Address, fromBlock, toBlock uint64) ([]supplyEvent, error) {
+	zeroTopic := common.BytesToHash(common.Address{}.Bytes())
+	mintLogs, err := utils.FilterLogs(cl, []common.Address{tokenAddress}, [][]common.Hash{{transferEventID}, {zeroTopic}}, fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get mint logs:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+	burnLogs, err := utils.FilterLogs(cl, []common.Address{tokenAddress}, [][]common.Hash{{transferEventID}, nil, {zeroTopic}}, fromBlock, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to get burn logs:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+
+	blockTimes := make(map[uint64]time.Time)
+	var events []supplyEvent
+	for _, log := range append(mintLogs, burnLogs...) {
+		if len(log.Topics) != 3 || len(log.Data) != 32 || log.Removed {
+			continue
+		}
+		from := common.BytesToAddress(log.Topics[1].Bytes())
+		to := common.BytesToAddress(log.Topics[2].Bytes())
+		// A transfer from and to the zero address is in both lists and changes nothing
+		if from == (common.Address{}) && to == (common.Address{}) {
+			continue
+		}
+		mint := from == (common.Address{})
+		timestamp, err := utils.GetBlockTime(cl, log.BlockNumber, blockTimes)
+		if err != nil {
+			return nil, err
+		}
+		account := to
+		if !mint {
+			account = from
+		}
+		events = append(events, supplyEvent{
+			BlockNumber: log.BlockNumber,
+			Timestamp:   timestamp,
+			TxHash:      log.TxHash,
+			Mint:        mint,
+			Account:     account,
+			Value:       new(big.Int).SetBytes(log.Data),
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].BlockNumber < events[j].BlockNumber
+	})
+	return events, nil
+}
@@ -0,0 +1,266 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/config"
+	"github.com/zachmdsi/go-token-cli/internal/core/contracts"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	The timeline puts the milestones of a launch in block order:
+
+		contract_created     the token's deployment
+		pair_created         the Uniswap V2 WETH pair's creation block
+		liquidity_added      the first Mint on the pair
+		trading_enabled      the first Swap on the pair
+		ownership_renounced  ownership moved to the zero or dead address
+		lp_locked            LP tokens sent to a configured locker
+		lp_burned            LP tokens sent to the zero or dead address by a holder
+		liquidity_removed    every Burn on the pair
+		price_ath            the highest price in the pair's Sync history
+		price_collapse       the first point after the high at least rugDropPct below it
+
+	The first swap is searched in windows after the first liquidity add, since a pair's
+	full swap history can be much larger than the few blocks it takes to find it.
+*/
+
+const (
+	TimelineContractCreated    = "contract_created"
+	TimelinePairCreated        = "pair_created"
+	TimelineLiquidityAdded     = "liquidity_added"
+	TimelineTradingEnabled     = "trading_enabled"
+	TimelineOwnershipRenounced = "ownership_renounced"
+	TimelineLPLocked           = "lp_locked"
+	TimelineLPBurned           = "lp_burned"
+	TimelineLiquidityRemoved   = "liquidity_removed"
+	TimelinePriceATH           = "price_ath"
+	TimelinePriceCollapse      = "price_collapse"
+
+	firstSwapWindow    = 2000
+	maxFirstSwapBlocks = 50000
+)
+
+func GenerateTimeline(conf config.Config, tokenAddress common.Address) (*types.TokenTimeline, error) {
+	cl, err := ethclient.Dial(conf.EthNodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
+	}
+
+	creation, err := contracts.GetContractCreator(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetContractCreator() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+	ownership, err := contracts.GetOwnership(cl, tokenAddress, creation.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetOwnership() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+	pair, err := dexes.GetUniswapPair(cl, tokenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("\nGetUniswapPair() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	}
+
+	return BuildTimeline(cl, tokenAddress, creation, ownership, pair, conf.LPLockers)
+}
+
+// BuildTimeline collects the launch milestones of a token, pair may be nil for tokens
+// that were never listed
+func BuildTimeline(cl *ethclient.Client, tokenAddress common.Address, creation *types.ContractCreation, ownership *types.Ownership, pair *types.UniswapPair, lockers []config.LockerConfig) (*types.TokenTimeline, error) {
+	timeline := &types.TokenTimeline{Token: tokenAddress}
+	add := func(kind string, blockNumber uint64, timestamp time.Time, txHash common.Hash, detail string) {
+		timeline.Events = append(timeline.Events, types.TimelineEvent{
+			Kind:        kind,
+			BlockNumber: blockNumber,
+			Timestamp:   timestamp,
+			TxHash:      txHash,
+			Detail:      detail,
+		})
+	}
+
+	add(TimelineContractCreated, creation.BlockNumber, creation.Timestamp, creation.TxHash, "deployed by "+creation.Creator.Hex())
+	if ownership != nil && ownership.RenouncedAt != nil {
+		r := ownership.RenouncedAt
+		add(TimelineOwnershipRenounced, r.BlockNumber, r.Timestamp, r.TxHash, "ownership moved from "+r.PreviousOwner.Hex())
+	}
+
+	if pair != nil {
+		timeline.Pair = pair.Address
+		if err := addPairEvents(cl, pair, lockers, add); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(timeline.Events, func(i, j int) bool {
+		return timeline.Events[i].BlockNumber < timeline.Events[j].BlockNumber
+	})
+	return timeline, nil
+}
+
+func addPairEvents(cl *ethclient.Client, pair *types.UniswapPair, lockers []config.LockerConfig, add func(string, uint64, time.Time, common.Hash, string)) error {
+	pairBlock, err := utils.FindCreationBlock(cl, pair.Address)
+	if err != nil {
+		return fmt.Errorf("\nFindCreationBlock() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+	pairTime, err := utils.GetBlockTime(cl, pairBlock, nil)
+	if err != nil {
+		return err
+	}
+	add(TimelinePairCreated, pairBlock, pairTime, common.Hash{}, "pair "+pair.Address.Hex())
+
+	events, err := dexes.GetLiquidityEvents(cl, pair, pairBlock, blockNum)
+	if err != nil {
+		return fmt.Errorf("\nGetLiquidityEvents() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+	var firstAdd *types.LiquidityEvent
+	for _, event := range events {
+		if event.Add {
+			if firstAdd == nil {
+				firstAdd = event
+				add(TimelineLiquidityAdded, event.BlockNumber, event.Timestamp, event.TxHash,
+					fmt.Sprintf("%s WETH and %s tokens by %s", event.WETHAmount.Text('f', 4), event.TokenAmount.Text('f', 2), event.Provider))
+			}
+			continue
+		}
+		add(TimelineLiquidityRemoved, event.BlockNumber, event.Timestamp, event.TxHash,
+			fmt.Sprintf("%s WETH removed by %s", event.WETHAmount.Text('f', 4), event.Provider))
+	}
+
+	if firstAdd != nil {
+		swap, err := findFirstSwap(cl, pair, firstAdd.BlockNumber, blockNum)
+		if err != nil {
+			return err
+		}
+		if swap != nil {
+			side := "sell"
+			if swap.Buy {
+				side = "buy"
+			}
+			add(TimelineTradingEnabled, swap.BlockNumber, swap.Timestamp, swap.TxHash,
+				fmt.Sprintf("first %s, %s WETH", side, swap.WETHAmount.Text('f', 4)))
+		}
+	}
+
+	transfers, err := dexes.GetLPTransfers(cl, pair, pairBlock, blockNum)
+	if err != nil {
+		return fmt.Errorf("\nGetLPTransfers() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+	lockerNames := make(map[common.Address]string)
+	for _, locker := range lockers {
+		if common.IsHexAddress(locker.Address) {
+			lockerNames[common.HexToAddress(locker.Address)] = locker.Name
+		}
+	}
+	for _, transfer := range transfers {
+		// Mints and the pair burning returned LP are liquidity events, not holder moves
+		if transfer.From == (common.Address{}) || transfer.From == pair.Address {
+			continue
+		}
+		if name, ok := lockerNames[transfer.To]; ok {
+			add(TimelineLPLocked, transfer.BlockNumber, transfer.Timestamp, transfer.TxHash,
+				fmt.Sprintf("%s LP locked in %s by %s", transfer.Value, name, transfer.From))
+		} else if transfer.To == (common.Address{}) || transfer.To == utils.DeadAddress {
+			add(TimelineLPBurned, transfer.BlockNumber, transfer.Timestamp, transfer.TxHash,
+				fmt.Sprintf("%s LP burned by %s", transfer.Value, transfer.From))
+		}
+	}
+
+	history, err := dexes.GetReserveHistory(cl, pair, pairBlock, blockNum)
+	if err != nil {
+		return fmt.Errorf("\nGetReserveHistory() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+	}
+	var ath *types.Reserves
+	athIndex := 0
+	for i, reserves := range history {
+		if reserves.PriceInWETH != nil && (ath == nil || reserves.PriceInWETH.Cmp(ath.PriceInWETH) > 0) {
+			ath, athIndex = reserves, i
+		}
+	}
+	if ath == nil {
+		return nil
+	}
+	add(TimelinePriceATH, ath.BlockNumber, ath.Timestamp, ath.TxHash, "price "+ath.PriceInWETH.Text('g', 8)+" WETH")
+	for _, reserves := range history[athIndex+1:] {
+		if reserves.PriceInWETH == nil {
+			continue
+		}
+		if dropPct := dropFromPeakPct(ath.PriceInWETH, reserves.PriceInWETH); dropPct >= rugDropPct {
+			add(TimelinePriceCollapse, reserves.BlockNumber, reserves.Timestamp, reserves.TxHash,
+				fmt.Sprintf("price %s WETH, %.1f%% below the high", reserves.PriceInWETH.Text('g', 8), dropPct))
+			break
+		}
+	}
+	return nil
+}
+
+// findFirstSwap searches forward from the first liquidity add in windows of
+// firstSwapWindow blocks, giving up after maxFirstSwapBlocks
+func findFirstSwap(cl *ethclient.Client, pair *types.UniswapPair, fromBlock, toBlock uint64) (*types.Swap, error) {
+	if toBlock > fromBlock+maxFirstSwapBlocks {
+		toBlock = fromBlock + maxFirstSwapBlocks
+	}
+	for start := fromBlock; start <= toBlock; start += firstSwapWindow {
+		end := start + firstSwapWindow - 1
+		if end > toBlock {
+			end = toBlock
+		}
+		swaps, err := dexes.GetUniswapSwaps(cl, pair, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("\nGetUniswapSwaps() failed:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+		}
+		if len(swaps) > 0 {
+			return swaps[0], nil
+		}
+	}
+	return nil, nil
+}
+
+func PrintTimeline(timeline *types.TokenTimeline) {
+	fmt.Printf("\nToken:                 %s\n", timeline.Token)
+	if timeline.Pair != (common.Address{}) {
+		fmt.Printf("Pair:                  %s\n", timeline.Pair)
+	}
+	fmt.Println()
+	printTimelineEvents(timeline, "")
+}
+
+func printTimelineEvents(timeline *types.TokenTimeline, indent string) {
+	var previous time.Time
+	for _, event := range timeline.Events {
+		elapsed := ""
+		if !previous.IsZero() {
+			elapsed = "+" + formatElapsed(event.Timestamp.Sub(previous))
+		}
+		previous = event.Timestamp
+		fmt.Printf("%s%-10d %-20s %-9s %-20s %s\n", indent,
+			event.BlockNumber,
+			event.Timestamp.Format("2006-01-02 15:04:05"),
+			elapsed,
+			event.Kind,
+			event.Detail,
+		)
+	}
+}
+
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	}
+	return fmt.Sprintf("%.1fd", d.Hours()/24)
+}
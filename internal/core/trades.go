@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/zachmdsi/go-token-cli/internal/core/dexes"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+	"github.com/zachmdsi/go-token-cli/internal/utils"
+)
+
+/*
+	The trade tape polls for new blocks and decodes the Swap and Sync events of the
+	token's Uniswap V2 WETH pair. A pair emits Sync right before Swap in the same
+	transaction, so the last Sync seen gives the price after each trade.
+*/
+
+func StreamTrades(ethNodeURL string, tokenAddress common.Address, backfillBlocks uint64, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		return fmt.Errorf("\nPoll interval must be positive, got %s", pollInterval)
+	}
+	cl, err := ethclient.Dial(ethNodeURL)
+	if err != nil {
+		return fmt.Errorf("\nFailed to dial eth node: %v", err.Error())
+	}
+
+	pair, err := dexes.GetUniswapPair(cl, tokenAddress)
+	if err != nil {
+		return fmt.Errorf("\nGetUniswapPair() failed:\n\tToken Address: %s\n\tError: %v", tokenAddress, err)
+	} else if pair == nil {
+		return fmt.Errorf("\nNo Uniswap V2 WETH pair found:\n\tToken Address: %s", tokenAddress)
+	}
+	swapID, err := dexes.GetPairEventID("Swap")
+	if err != nil {
+		return err
+	}
+	syncID, err := dexes.GetPairEventID("Sync")
+	if err != nil {
+		return err
+	}
+
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+	var lastBlockNum uint64
+	if backfillBlocks < blockNum {
+		lastBlockNum = blockNum - backfillBlocks
+	}
+
+	fmt.Printf("\nWatching trades on pair %s from block %d\n\n", pair.Address, lastBlockNum+1)
+	fmt.Printf("%-10s %-66s %-42s %-4s %24s %20s %14s %24s\n", "Block", "Tx Hash", "Trader", "Side", "Tokens", "ETH", "USD", "Price After (WETH)")
+
+	for {
+		blockNum, err = cl.BlockNumber(context.Background())
+		if err != nil {
+			return fmt.Errorf("\nFailed to get block number: %v", err)
+		}
+		if blockNum <= lastBlockNum {
+			time.Sleep(pollInterval)
+			continue
+		}
+
+		logs, err := utils.FilterLogs(cl, []common.Address{pair.Address}, [][]common.Hash{{swapID, syncID}}, lastBlockNum+1, blockNum)
+		if err != nil {
+			return fmt.Errorf("\nFailed to get pair logs:\n\tPair Address: %s\n\tError: %v", pair.Address, err)
+		}
+
+		var ethPriceInUSD *big.Float
+		if len(logs) > 0 {
+			ethPriceInUSD, err = dexes.GetETHPriceInUSD(cl)
+			if err != nil {
+				return fmt.Errorf("\nGetETHPriceInUSD() failed: %v", err)
+			}
+		}
+
+		// Each poll only reads blocks after the last one, so block times are cached per
+		// batch rather than for the life of the stream
+		blockTimes := make(map[uint64]time.Time)
+		var reserves *types.Reserves
+		for _, log := range logs {
+			timestamp, err := utils.GetBlockTime(cl, log.BlockNumber, blockTimes)
+			if err != nil {
+				return err
+			}
+			if log.Topics[0] == syncID {
+				reserves, err = dexes.DecodeUniswapSync(pair, log, timestamp)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			swap, err := dexes.DecodeUniswapSwap(pair, log, timestamp)
+			if err != nil {
+				return err
+			}
+			trader, err := utils.GetTransactionSender(cl, swap.TxHash)
+			if err != nil {
+				return err
+			}
+			trade := &types.Trade{
+				Swap:     swap,
+				Trader:   trader,
+				ValueUSD: new(big.Float).Mul(swap.WETHAmount, ethPriceInUSD),
+			}
+			if reserves != nil && reserves.TxHash == swap.TxHash {
+				trade.PriceAfterInWETH = reserves.PriceInWETH
+			}
+			printTrade(trade)
+		}
+
+		lastBlockNum = blockNum
+	}
+}
+
+func printTrade(trade *types.Trade) {
+	side := "SELL"
+	if trade.Swap.Buy {
+		side = "BUY"
+	}
+	priceAfter := "-"
+	if trade.PriceAfterInWETH != nil {
+		priceAfter = trade.PriceAfterInWETH.Text('g', 12)
+	}
+	fmt.Printf("%-10d %-66s %-42s %-4s %24s %20s %14s %24s\n",
+		trade.Swap.BlockNumber,
+		trade.Swap.TxHash.Hex(),
+		trade.Trader.Hex(),
+		side,
+		trade.Swap.TokenAmount.Text('f', 4),
+		trade.Swap.WETHAmount.Text('f', 6),
+		trade.ValueUSD.Text('f', 2),
+		priceAfter,
+	)
+}
@@ -0,0 +1,296 @@
+package etherscan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zachmdsi/go-token-cli/internal/store"
+	"github.com/zachmdsi/go-token-cli/internal/types"
+)
+
+/*
+	A small client for the Etherscan V2 contract API, which serves every chain from one
+	endpoint and picks the chain with the chainid parameter. Blockscout and most other
+	explorers serve the same module/action query interface and ignore chainid, so
+	pointing the base URL at one of them (or at a local stub server) works the same way.
+
+	Requests are spaced out to stay under the explorer's rate limit and retried with a
+	backoff when the explorer reports the limit was hit anyway. Verified source and
+	contract creations never change, so they are cached in the store. Unverified
+	contracts are not cached since they can be verified later.
+*/
+
+const (
+	DefaultBaseURL = "https://api.etherscan.io/v2/api"
+	// DefaultChainID is Ethereum mainnet
+	DefaultChainID = 1
+	// DefaultRateLimit is requests per second, the free Etherscan tier allows 5
+	DefaultRateLimit = 5
+
+	cacheNamespace = "etherscan"
+	maxRetries     = 3
+	notVerified    = "Contract source code not verified"
+	noData         = "No data found"
+)
+
+// retryBackoff is the wait before the first retry, doubling with each one after
+var retryBackoff = time.Second
+
+type Client struct {
+	baseURL    string
+	apiKey     string
+	chainID    uint64
+	httpClient *http.Client
+	cache      *store.Store
+
+	mu       sync.Mutex
+	interval time.Duration
+	last     time.Time
+}
+
+// NewClient creates a client for the explorer API at baseURL, using the Etherscan
+// endpoint when it is empty and mainnet when chainID is 0. requestsPerSecond falls back
+// to DefaultRateLimit when not positive, and a nil cache disables caching.
+func NewClient(baseURL, apiKey string, chainID uint64, requestsPerSecond float64, cache *store.Store) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if chainID == 0 {
+		chainID = DefaultChainID
+	}
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = DefaultRateLimit
+	}
+	return &Client{
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		chainID:    chainID,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		cache:      cache,
+		interval:   time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
+type response struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+type sourceResult struct {
+	SourceCode           string `json:"SourceCode"`
+	ABI                  string `json:"ABI"`
+	ContractName         string `json:"ContractName"`
+	CompilerVersion      string `json:"CompilerVersion"`
+	OptimizationUsed     string `json:"OptimizationUsed"`
+	Runs                 string `json:"Runs"`
+	ConstructorArguments string `json:"ConstructorArguments"`
+	EVMVersion           string `json:"EVMVersion"`
+	LicenseType          string `json:"LicenseType"`
+	Proxy                string `json:"Proxy"`
+	Implementation       string `json:"Implementation"`
+}
+
+type creationResult struct {
+	ContractAddress string `json:"contractAddress"`
+	ContractCreator string `json:"contractCreator"`
+	TxHash          string `json:"txHash"`
+}
+
+// GetSourceCode returns the published source of a contract. Unverified contracts are
+// returned with Verified unset rather than as an error.
+func (c *Client) GetSourceCode(address common.Address) (*types.ContractSource, error) {
+	source := &types.ContractSource{}
+	if found, err := c.load("source", address, source); err != nil || found {
+		return source, err
+	}
+
+	var results []sourceResult
+	err := c.get(url.Values{
+		"module":  {"contract"},
+		"action":  {"getsourcecode"},
+		"address": {address.Hex()},
+	}, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return &types.ContractSource{Address: address}, nil
+	}
+
+	r := results[0]
+	source = &types.ContractSource{
+		Address:              address,
+		Verified:             r.SourceCode != "" && r.ABI != notVerified,
+		ContractName:         r.ContractName,
+		CompilerVersion:      r.CompilerVersion,
+		OptimizationUsed:     r.OptimizationUsed == "1",
+		EVMVersion:           r.EVMVersion,
+		LicenseType:          r.LicenseType,
+		SourceCode:           r.SourceCode,
+		ConstructorArguments: r.ConstructorArguments,
+		Proxy:                r.Proxy == "1",
+	}
+	source.Runs, _ = strconv.Atoi(r.Runs)
+	if source.Verified {
+		source.ABI = r.ABI
+	}
+	if common.IsHexAddress(r.Implementation) {
+		source.Implementation = common.HexToAddress(r.Implementation)
+	}
+
+	if source.Verified {
+		if err := c.save("source", address, source); err != nil {
+			return nil, err
+		}
+	}
+	return source, nil
+}
+
+// GetABI returns the JSON ABI of a verified contract, or an empty string when the
+// contract is not verified
+func (c *Client) GetABI(address common.Address) (string, error) {
+	source, err := c.GetSourceCode(address)
+	if err != nil {
+		return "", err
+	}
+	return source.ABI, nil
+}
+
+// GetContractCreation returns the deployer and deployment transaction of a contract, or
+// nil when the explorer has no record of it. The block number and timestamp aren't part
+// of the explorer's answer and are left for the caller to fill in from the transaction
+// receipt.
+func (c *Client) GetContractCreation(address common.Address) (*types.ContractCreation, error) {
+	creation := &types.ContractCreation{}
+	if found, err := c.load("creation", address, creation); err != nil || found {
+		return creation, err
+	}
+
+	var results []creationResult
+	err := c.get(url.Values{
+		"module":            {"contract"},
+		"action":            {"getcontractcreation"},
+		"contractaddresses": {address.Hex()},
+	}, &results)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 || results[0].TxHash == "" {
+		return nil, nil
+	}
+
+	creation = &types.ContractCreation{
+		Contract: address,
+		Creator:  common.HexToAddress(results[0].ContractCreator),
+		TxHash:   common.HexToHash(results[0].TxHash),
+	}
+	if err := c.save("creation", address, creation); err != nil {
+		return nil, err
+	}
+	return creation, nil
+}
+
+// get runs a query and decodes its result into v, waiting out the rate limit
+func (c *Client) get(params url.Values, v interface{}) error {
+	params.Set("chainid", strconv.FormatUint(c.chainID, 10))
+	if c.apiKey != "" {
+		params.Set("apikey", c.apiKey)
+	}
+	query := c.baseURL + "?" + params.Encode()
+
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(query)
+		if err != nil {
+			return err
+		}
+
+		if resp.Status == "1" {
+			if err := json.Unmarshal(resp.Result, v); err != nil {
+				return fmt.Errorf("\nFailed to decode %s result: %v", params.Get("action"), err)
+			}
+			return nil
+		}
+
+		// Failed calls carry the reason as a plain string result
+		var reason string
+		json.Unmarshal(resp.Result, &reason)
+		if strings.Contains(strings.ToLower(reason), "rate limit") && attempt < maxRetries {
+			time.Sleep(backoff)
+			backoff *= 2
+			continue
+		}
+		// Etherscan answers an unverified contract's getsourcecode with status 1, but
+		// some explorers report it as an error with the same message. Lookups with
+		// nothing to return fail with "No data found".
+		if reason == notVerified || resp.Message == noData {
+			return json.Unmarshal([]byte("[]"), v)
+		}
+		return fmt.Errorf("\nExplorer %s request failed: %s: %s", params.Get("action"), resp.Message, reason)
+	}
+}
+
+func (c *Client) do(query string) (*response, error) {
+	c.wait()
+
+	httpResp, err := c.httpClient.Get(query)
+	if err != nil {
+		return nil, fmt.Errorf("\nExplorer request failed: %v", err)
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("\nFailed to read explorer response: %v", err)
+	}
+	if httpResp.StatusCode == http.StatusTooManyRequests {
+		return &response{Status: "0", Result: json.RawMessage(`"Max rate limit reached"`)}, nil
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("\nExplorer returned %s", httpResp.Status)
+	}
+
+	resp := &response{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, fmt.Errorf("\nFailed to decode explorer response: %v", err)
+	}
+	return resp, nil
+}
+
+// wait blocks until at least one interval has passed since the previous request
+func (c *Client) wait() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if next := c.last.Add(c.interval); time.Now().Before(next) {
+		time.Sleep(time.Until(next))
+	}
+	c.last = time.Now()
+}
+
+func (c *Client) load(kind string, address common.Address, v interface{}) (bool, error) {
+	if c.cache == nil {
+		return false, nil
+	}
+	return c.cache.Load(cacheNamespace, c.cacheKey(kind, address), v)
+}
+
+func (c *Client) save(kind string, address common.Address, v interface{}) error {
+	if c.cache == nil {
+		return nil
+	}
+	return c.cache.Save(cacheNamespace, c.cacheKey(kind, address), v)
+}
+
+// cacheKey keeps the same address on different chains apart
+func (c *Client) cacheKey(kind string, address common.Address) string {
+	return kind + "_" + strconv.FormatUint(c.chainID, 10) + "_" + address.Hex()
+}
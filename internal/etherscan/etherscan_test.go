@@ -0,0 +1,168 @@
+package etherscan
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zachmdsi/go-token-cli/internal/store"
+)
+
+var testAddress = common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+const verifiedSource = `{"status":"1","message":"OK","result":[{"SourceCode":"contract Token {}","ABI":"[]","ContractName":"Token","CompilerVersion":"v0.8.20","OptimizationUsed":"1","Runs":"200","ConstructorArguments":"","EVMVersion":"Default","LicenseType":"MIT","Proxy":"0","Implementation":""}]}`
+
+const unverifiedSource = `{"status":"1","message":"OK","result":[{"SourceCode":"","ABI":"Contract source code not verified","ContractName":"","CompilerVersion":"","OptimizationUsed":"","Runs":"","ConstructorArguments":"","EVMVersion":"","LicenseType":"","Proxy":"0","Implementation":""}]}`
+
+// stubServer answers each request with the next of responses, repeating the last one,
+// and counts the requests it served
+func stubServer(t *testing.T, responses ...func(w http.ResponseWriter, r *http.Request)) (*httptest.Server, *int32) {
+	t.Helper()
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&count, 1)) - 1
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		responses[i](w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &count
+}
+
+func respond(body string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}
+}
+
+func newTestClient(t *testing.T, baseURL string, cached bool) *Client {
+	t.Helper()
+	retryBackoff = time.Millisecond
+	var cache *store.Store
+	if cached {
+		var err error
+		cache, err = store.Open(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewClient(baseURL, "key", 0, 1000, cache)
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		limited func(w http.ResponseWriter, r *http.Request)
+	}{
+		{"rate limit result", respond(`{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`)},
+		{"http 429", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, count := stubServer(t, tt.limited, tt.limited, respond(verifiedSource))
+			source, err := newTestClient(t, server.URL, false).GetSourceCode(testAddress)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !source.Verified || source.ContractName != "Token" || source.Runs != 200 {
+				t.Errorf("unexpected source %+v", source)
+			}
+			if *count != 3 {
+				t.Errorf("got %d requests, want 3", *count)
+			}
+		})
+	}
+}
+
+func TestRateLimitGivesUp(t *testing.T) {
+	server, count := stubServer(t, respond(`{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`))
+	if _, err := newTestClient(t, server.URL, false).GetSourceCode(testAddress); err == nil {
+		t.Error("expected an error once retries run out")
+	}
+	if *count != maxRetries+1 {
+		t.Errorf("got %d requests, want %d", *count, maxRetries+1)
+	}
+}
+
+func TestQueryParameters(t *testing.T) {
+	server, _ := stubServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("chainid") != "1" || query.Get("apikey") != "key" || query.Get("address") != testAddress.Hex() {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, verifiedSource)
+	})
+	if _, err := newTestClient(t, server.URL, false).GetSourceCode(testAddress); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSourceCaching(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		verified bool
+		requests int32
+	}{
+		{"verified source is cached", verifiedSource, true, 1},
+		{"unverified source is refetched", unverifiedSource, false, 2},
+		{"unverified as an error", `{"status":"0","message":"NOTOK","result":"Contract source code not verified"}`, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, count := stubServer(t, respond(tt.response))
+			client := newTestClient(t, server.URL, true)
+			for i := 0; i < 2; i++ {
+				source, err := client.GetSourceCode(testAddress)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if source.Verified != tt.verified {
+					t.Errorf("got verified %v, want %v", source.Verified, tt.verified)
+				}
+				if !tt.verified && source.SourceCode != "" {
+					t.Errorf("unverified source has code %q", source.SourceCode)
+				}
+			}
+			if *count != tt.requests {
+				t.Errorf("got %d requests, want %d", *count, tt.requests)
+			}
+		})
+	}
+}
+
+func TestContractCreation(t *testing.T) {
+	txHash := "0x2222222222222222222222222222222222222222222222222222222222222222"
+	server, count := stubServer(t, respond(`{"status":"1","message":"OK","result":[{"contractAddress":"`+testAddress.Hex()+`","contractCreator":"0x3333333333333333333333333333333333333333","txHash":"`+txHash+`"}]}`))
+	client := newTestClient(t, server.URL, true)
+	for i := 0; i < 2; i++ {
+		creation, err := client.GetContractCreation(testAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creation == nil || creation.TxHash != common.HexToHash(txHash) || creation.Creator != common.HexToAddress("0x3333333333333333333333333333333333333333") {
+			t.Fatalf("unexpected creation %+v", creation)
+		}
+	}
+	if *count != 1 {
+		t.Errorf("got %d requests, want 1", *count)
+	}
+}
+
+func TestContractCreationNoData(t *testing.T) {
+	server, _ := stubServer(t, respond(`{"status":"0","message":"No data found","result":null}`))
+	creation, err := newTestClient(t, server.URL, true).GetContractCreation(testAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creation != nil {
+		t.Errorf("got %+v, want nil", creation)
+	}
+}
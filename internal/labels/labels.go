@@ -0,0 +1,83 @@
+package labels
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+/*
+	Address labels are read from a local CSV file with one address per line:
+
+		address,label,category
+		0x910Cbd523D972eb0a6f4cAe4618aD62622b39DbF,Tornado Cash 10 ETH,mixer
+		0x28C6c06298d514Db089934071355E5743bf21d60,Binance 14,cex
+
+	The header line is optional and lines starting with # are ignored. Categories are
+	free form, but mixer, bridge and cex are the ones funding traces look for and mev
+	marks known MEV bot contracts for sniper detection.
+*/
+
+const (
+	CategoryMixer  = "mixer"
+	CategoryBridge = "bridge"
+	CategoryCEX    = "cex"
+	CategoryMEV    = "mev"
+)
+
+type Label struct {
+	Name     string
+	Category string
+}
+
+type Labels map[common.Address]Label
+
+// Load reads a labels file, returning no labels when path is empty or doesn't exist
+func Load(path string) (Labels, error) {
+	labels := make(Labels)
+	if path == "" {
+		return labels, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return labels, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("\nFailed to open labels file %s: %v", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("\nFailed to read labels file %s: %v", path, err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "address") {
+			continue
+		}
+		if len(record) < 2 || !common.IsHexAddress(record[0]) {
+			return nil, fmt.Errorf("\nInvalid label on line %d of %s", line, path)
+		}
+		label := Label{Name: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			label.Category = strings.ToLower(strings.TrimSpace(record[2]))
+		}
+		labels[common.HexToAddress(record[0])] = label
+	}
+	return labels, nil
+}
+
+func (l Labels) Lookup(address common.Address) (Label, bool) {
+	label, ok := l[address]
+	return label, ok
+}
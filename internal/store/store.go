@@ -0,0 +1,93 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+	The store keeps data that is expensive to rebuild (indexes, fingerprints, monitor
+	state) between runs as JSON files, one file per key:
+
+		<data_dir>/<namespace>/<key>.json
+
+	A store opened without a directory keeps nothing, so every run starts from scratch.
+*/
+
+type Store struct {
+	dir string
+}
+
+func Open(dir string) (*Store, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("\nFailed to create data directory %s: %v", dir, err)
+		}
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Load decodes the value saved under namespace/key into v, reporting whether it existed
+func (s *Store) Load(namespace, key string, v interface{}) (bool, error) {
+	if s.dir == "" {
+		return false, nil
+	}
+	data, err := os.ReadFile(s.path(namespace, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("\nFailed to decode %s/%s: %v", namespace, key, err)
+	}
+	return true, nil
+}
+
+func (s *Store) Save(namespace, key string, v interface{}) error {
+	if s.dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	path := s.path(namespace, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so an interrupted run can't leave a truncated file behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, os.FileMode(0644)); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Keys lists the keys saved in namespace
+func (s *Store) Keys(namespace string) ([]string, error) {
+	if s.dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(s.dir, namespace))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, entry := range entries {
+		if name := entry.Name(); !entry.IsDir() && strings.HasSuffix(name, ".json") {
+			keys = append(keys, strings.TrimSuffix(name, ".json"))
+		}
+	}
+	return keys, nil
+}
+
+func (s *Store) path(namespace, key string) string {
+	return filepath.Join(s.dir, namespace, strings.ToLower(key)+".json")
+}
@@ -17,21 +17,47 @@ type Token struct {
 	TotalSupply          *big.Int
 	ContractCreationDate time.Time
 	ContractCreator      common.Address
+	Deployer             *DeployerReport
+	Ownership            *Ownership
+	Capabilities         []Capability
+	Proxy                *ProxyInfo
+	Source               *ContractSource `json:"-"`
+	SourceScan           *SourceReport
+	CodeHash             common.Hash
+	Deployment           *DeploymentReport
+	Clone                *CloneFamily
 
 	// Calculated Data
 	CirculatingSupply    *big.Int
+	SupplyExclusions     []SupplyExclusion
 	MarketCap            *big.Float
+	FullyDilutedValue    *big.Float
 	Volume1h            *big.Float
 	PriceChange1h       *big.Float
 	Holders              uint64
 	LargestHolders       []TokenHolder
 	TokenTransfers       uint64
+	Concentration        *HolderConcentration
+	SupplyCheck          *SupplyConsistency
+	Clusters             []*WalletCluster
 
 	// DEX Data
 	UniswapPriceInWETH   *big.Float
 	UniswapLink          string
 	SushiPriceInWETH     *big.Float
 	SushiLink            string
+	LPAnalysis           *LPAnalysis
+	Snipers              *SniperReport
+
+	// Simulation Data
+	Honeypot             *HoneypotResult
+	TransferRestrictions *TransferRestrictions
+
+	// Risk
+	Risk                 *RiskScore
+
+	// Timeline
+	Timeline             *TokenTimeline
 }
 
 type TokenHolder struct {
@@ -43,3 +69,533 @@ type TokenHolder struct {
 type ERC20 struct {
 	Contract *bind.BoundContract
 }
+
+type UniswapPair struct {
+	Address       common.Address
+	Contract      *bind.BoundContract
+	Token         common.Address
+	TokenIsToken0 bool
+	TokenDecimals uint8
+}
+
+// Swap is a decoded pair Swap event, normalized to the token and WETH side
+type Swap struct {
+	BlockNumber uint64
+	Timestamp   time.Time
+	TxHash      common.Hash
+	LogIndex    uint
+	Sender      common.Address
+	Recipient   common.Address
+	Buy         bool
+	TokenAmount *big.Float
+	WETHAmount  *big.Float
+	PriceInWETH *big.Float
+}
+
+type Candle struct {
+	Start      time.Time
+	Open       *big.Float
+	High       *big.Float
+	Low        *big.Float
+	Close      *big.Float
+	Volume     *big.Float
+	VolumeWETH *big.Float
+	Trades     uint64
+}
+
+// Reserves is the state of a pair after a Sync event, normalized to the token and WETH side
+type Reserves struct {
+	BlockNumber  uint64
+	Timestamp    time.Time
+	TxHash       common.Hash
+	LogIndex     uint
+	TokenReserve *big.Float
+	WETHReserve  *big.Float
+	PriceInWETH  *big.Float
+}
+
+type Trade struct {
+	Swap             *Swap
+	Trader           common.Address
+	ValueUSD         *big.Float
+	PriceAfterInWETH *big.Float
+}
+
+type LiquidityDrop struct {
+	BlockNumber     uint64
+	Timestamp       time.Time
+	TxHash          common.Hash
+	FromWETHReserve *big.Float
+	ToWETHReserve   *big.Float
+	DropPct         float64
+}
+
+type PriceDrop struct {
+	BlockNumber uint64
+	Timestamp   time.Time
+	TxHash      common.Hash
+	FromPrice   *big.Float
+	ToPrice     *big.Float
+	DropPct     float64
+}
+
+type LiquidityEvent struct {
+	BlockNumber uint64
+	Timestamp   time.Time
+	TxHash      common.Hash
+	Provider    common.Address
+	Add         bool
+	TokenAmount *big.Float
+	WETHAmount  *big.Float
+	LPAmount    *big.Int
+}
+
+type LiquidityTimeline struct {
+	PairAddress           common.Address
+	Events                []*LiquidityEvent
+	InitialProvider       common.Address
+	InitialBlock          uint64
+	InitialTokenLiquidity *big.Float
+	InitialWETHLiquidity  *big.Float
+	InitialPriceInWETH    *big.Float
+	Removals              []*LiquidityEvent
+	LPMinted              *big.Int
+	LPRemoved             *big.Int
+	RemovedPct            float64
+}
+
+type ContractCreation struct {
+	Contract    common.Address
+	Creator     common.Address
+	TxHash      common.Hash
+	BlockNumber uint64
+	Timestamp   time.Time
+}
+
+const (
+	LPClassBurned      = "burned"
+	LPClassLocked      = "locked"
+	LPClassExpiredLock = "expired_lock"
+	LPClassDeployer    = "deployer"
+	LPClassEOA         = "eoa"
+	LPClassContract    = "contract"
+)
+
+type LPHolder struct {
+	Address    common.Address
+	Balance    *big.Int
+	Share      float64
+	Class      string
+	Label      string
+	UnlockDate *time.Time
+}
+
+type LPAnalysis struct {
+	PairAddress    common.Address
+	TotalSupply    *big.Int
+	Holders        []*LPHolder
+	BurnedPct      float64
+	LockedPct      float64
+	ExpiredLockPct float64
+	DeployerPct    float64
+	NextUnlock     *time.Time
+}
+
+type HoneypotResult struct {
+	BuyAmountWETH *big.Float
+	BuyTaxPct     float64
+	SellTaxPct    float64
+	BuyReverted   bool
+	SellReverted  bool
+	RevertReason  string
+	IsHoneypot    bool
+}
+
+// SimulatedTx is a call run in the simulator, kept as evidence for a finding
+type SimulatedTx struct {
+	BlockNumber  uint64
+	From         common.Address
+	To           common.Address
+	Input        string
+	Description  string
+	Success      bool
+	RevertReason string
+}
+
+type TransferRestrictions struct {
+	// NotSimulated is why the transfers couldn't be simulated, nothing else is set when it is
+	NotSimulated      string
+	FundingMethod     string
+	// SearchLimitPct is the share of the supply the senders held, limits above it can't be found
+	SearchLimitPct    float64
+	TransferFeePct    float64
+	MaxTransferAmount *big.Float
+	MaxWalletAmount   *big.Float
+	HasCooldown       bool
+	CooldownBlocks    uint64
+	Evidence          []*SimulatedTx
+}
+
+// HolderIndex is the token balance of every address, rebuilt from Transfer events and
+// kept up to date from LastBlock on
+type HolderIndex struct {
+	Token     common.Address              `json:"token"`
+	LastBlock uint64                      `json:"lastBlock"`
+	Transfers uint64                      `json:"transfers"`
+	Balances  map[common.Address]*big.Int `json:"balances"`
+}
+
+// SupplyExclusion is an address whose balance doesn't count towards the circulating supply
+type SupplyExclusion struct {
+	Address common.Address
+	Label   string
+	Balance *big.Int
+	Share   float64
+}
+
+// HolderConcentration measures how evenly the held supply is spread, leaving out LP
+// pools and burn addresses. Shares are of the remaining held supply.
+type HolderConcentration struct {
+	Holders     uint64
+	Top10Pct    float64
+	Top50Pct    float64
+	Gini        float64
+	Nakamoto    uint64
+	ContractPct float64
+	EOAPct      float64
+	Excluded    []common.Address
+}
+
+// DeployedContract is a contract created directly by a deployer's transaction
+type DeployedContract struct {
+	Address   common.Address
+	Nonce     uint64
+	IsToken   bool
+	Name      string
+	Symbol    string
+	Rugged    bool
+	RugReason string
+}
+
+// DeployerReport is the history of an address up to AtBlock, which for a token's
+// deployer is the block before the token was deployed
+type DeployerReport struct {
+	Address            common.Address
+	AtBlock            uint64
+	FirstActivityBlock uint64
+	FirstActivity      *time.Time
+	Balance            *big.Float
+	Nonce              uint64
+	Contracts          []*DeployedContract
+	TokenCount         int
+	RuggedCount        int
+	Funding            *FundingTrace
+}
+
+// FundingHop is the first transaction that gave Address ETH. Internal is set when the
+// ETH arrived through a contract call, in which case Funder is the called contract.
+type FundingHop struct {
+	Address          common.Address
+	Funder           common.Address
+	TxHash           common.Hash
+	BlockNumber      uint64
+	Timestamp        time.Time
+	Amount           *big.Float
+	Internal         bool
+	FunderIsContract bool
+	FunderLabel      string
+	FunderCategory   string
+}
+
+// FundingTrace follows first funding transactions backwards from an address until a
+// labelled source, a contract or the hop limit is reached
+type FundingTrace struct {
+	Hops           []*FundingHop
+	Source         common.Address
+	SourceLabel    string
+	SourceCategory string
+}
+
+// EarlyBuyer aggregates the buys received by one address in the blocks after launch
+type EarlyBuyer struct {
+	Address            common.Address
+	Sender             common.Address
+	FirstBlock         uint64
+	BlocksAfterLaunch  uint64
+	Buys               int
+	TokenAmount        *big.Float
+	SupplyPct          float64
+	MaxPriorityFeeGwei float64
+	Flags              []string
+}
+
+// SniperReport covers the buys in the launch block and the Blocks blocks after it
+type SniperReport struct {
+	PairAddress  common.Address
+	LaunchBlock  uint64
+	LaunchTxHash common.Hash
+	Blocks       uint64
+	EarlyBuyers  int
+	Snipers      []*EarlyBuyer
+	SnipedPct    float64
+}
+
+// WalletCluster is a group of holders linked by their funding or by transfers between
+// them, likely controlled by one party
+type WalletCluster struct {
+	Members []common.Address
+	Balance *big.Int
+	Share   float64
+	Reasons []string
+}
+
+const (
+	OwnerKindNone     = "none"
+	OwnerKindEOA      = "eoa"
+	OwnerKindMultisig = "multisig"
+	OwnerKindTimelock = "timelock"
+	OwnerKindContract = "contract"
+)
+
+type OwnershipTransfer struct {
+	BlockNumber   uint64
+	Timestamp     time.Time
+	TxHash        common.Hash
+	PreviousOwner common.Address
+	NewOwner      common.Address
+}
+
+// Ownership describes who controls a token's owner-only functions. Source is how the
+// owner was found, empty when the token has no detectable owner.
+type Ownership struct {
+	Owner             common.Address
+	Source            string
+	OwnerKind         string
+	Renounced         bool
+	RenouncedAt       *OwnershipTransfer
+	MultisigThreshold uint64
+	MultisigOwners    int
+	TimelockDelay     time.Duration
+	Transfers         []*OwnershipTransfer
+}
+
+// Capability is a privileged function or dangerous opcode found in a contract's code
+type Capability struct {
+	Name      string
+	Signature string
+	Selector  string
+}
+
+// ProxyInfo describes a proxy contract and where its logic lives. Minimal proxies
+// (EIP-1167) point at a fixed implementation and can't be upgraded.
+type ProxyInfo struct {
+	Kind           string
+	Implementation common.Address
+	Admin          common.Address
+	Upgradeable    bool
+}
+
+// RiskFactor is one signal's part of a risk score. Severity runs from 0 to 1 and the
+// factor contributes Weight * Severity points.
+type RiskFactor struct {
+	Name         string
+	Weight       float64
+	Severity     float64
+	Contribution float64
+	Evidence     string
+}
+
+type RiskScore struct {
+	Score   float64
+	Factors []RiskFactor
+}
+
+// ContractSource is a contract's verified source as published on a block explorer.
+// SourceCode is either plain Solidity or a standard JSON input with several files.
+type ContractSource struct {
+	Address              common.Address
+	Verified             bool
+	ContractName         string
+	CompilerVersion      string
+	OptimizationUsed     bool
+	Runs                 int
+	EVMVersion           string
+	LicenseType          string
+	SourceCode           string
+	ABI                  string
+	ConstructorArguments string
+	Proxy                bool
+	Implementation       common.Address
+}
+
+const (
+	SocialWebsite  = "website"
+	SocialTelegram = "telegram"
+	SocialTwitter  = "twitter"
+)
+
+// SourceFinding is a scam pattern matched in verified source, Line is 1-based within File
+type SourceFinding struct {
+	Pattern string
+	File    string
+	Line    int
+	Snippet string
+	Detail  string
+}
+
+type SocialLink struct {
+	Kind string
+	URL  string
+	File string
+	Line int
+}
+
+type SourceReport struct {
+	Files    int
+	Findings []SourceFinding
+	Links    []SocialLink
+}
+
+// Fingerprint identifies normalized runtime code, Hash matches exact copies and MinHash
+// estimates the similarity of edited ones
+type Fingerprint struct {
+	Hash    common.Hash `json:"hash"`
+	MinHash []uint32    `json:"min_hash"`
+}
+
+type CloneDeployment struct {
+	Token       common.Address `json:"token"`
+	Name        string         `json:"name"`
+	Symbol      string         `json:"symbol"`
+	Deployer    common.Address `json:"deployer"`
+	BlockNumber uint64         `json:"block_number"`
+	Rugged      bool           `json:"rugged"`
+	RugReason   string         `json:"rug_reason,omitempty"`
+	CheckedAt   time.Time      `json:"checked_at"`
+}
+
+// FingerprintRecord is a stored fingerprint with every token seen deploying that code
+type FingerprintRecord struct {
+	Fingerprint
+	Deployments []CloneDeployment `json:"deployments"`
+}
+
+// CloneFamily describes the previously seen tokens whose code matches a token's
+type CloneFamily struct {
+	Original         CloneDeployment
+	Exact            bool
+	Similarity       float64
+	PriorDeployments int
+	RuggedCount      int
+	Deployers        int
+}
+
+type ConstructorArg struct {
+	Name  string
+	Type  string
+	Value string
+	Note  string
+}
+
+// InitialAllocation is an address's net token balance change in the deployment transaction
+type InitialAllocation struct {
+	Address common.Address
+	Amount  *big.Float
+	Share   float64
+	Kind    string
+	Label   string
+}
+
+type DeploymentReport struct {
+	TxHash       common.Hash
+	FromFactory  bool
+	InitCodeSize int
+	Args         []ConstructorArg
+	ArgsDecoded  bool
+	Minted       *big.Float
+	Distribution []InitialAllocation
+}
+
+type LPTransfer struct {
+	BlockNumber uint64
+	Timestamp   time.Time
+	TxHash      common.Hash
+	From        common.Address
+	To          common.Address
+	Value       *big.Int
+}
+
+// TimelineEvent is one step of a token's launch, TxHash is empty when the event isn't
+// tied to a single transaction
+type TimelineEvent struct {
+	Kind        string
+	BlockNumber uint64
+	Timestamp   time.Time
+	TxHash      common.Hash
+	Detail      string
+}
+
+type TokenTimeline struct {
+	Token  common.Address
+	Pair   common.Address
+	Events []TimelineEvent
+}
+
+const (
+	TokenStatusActive    = "active"
+	TokenStatusRugged    = "rugged"
+	TokenStatusAbandoned = "abandoned"
+)
+
+type MonitorEvidence struct {
+	Kind        string      `json:"kind"`
+	BlockNumber uint64      `json:"block_number"`
+	Timestamp   time.Time   `json:"timestamp"`
+	TxHash      common.Hash `json:"tx_hash"`
+	Detail      string      `json:"detail"`
+}
+
+// TrackedToken is the monitor's persisted state for a token. LastBlock is the last block
+// evaluated and RecentReserves keeps the reserves inside the price drop window so drops
+// spanning two runs are still seen.
+type TrackedToken struct {
+	Token          common.Address    `json:"token"`
+	Symbol         string            `json:"symbol"`
+	Decimals       uint8             `json:"decimals"`
+	Deployer       common.Address    `json:"deployer"`
+	Owner          common.Address    `json:"owner"`
+	TrackedSince   time.Time         `json:"tracked_since"`
+	LastBlock      uint64            `json:"last_block"`
+	LastChecked    time.Time         `json:"last_checked"`
+	LastActivity   time.Time         `json:"last_activity"`
+	TotalSupply    *big.Int          `json:"total_supply"`
+	RecentReserves []*Reserves       `json:"recent_reserves"`
+	Status         string            `json:"status"`
+	StatusSince    time.Time         `json:"status_since"`
+	Evidence       []MonitorEvidence `json:"evidence"`
+}
+
+// SupplySample compares totalSupply() at a block with the supply implied by the mint and
+// burn Transfer events up to it, Difference is TotalSupply - EventSupply
+type SupplySample struct {
+	BlockNumber uint64
+	TotalSupply *big.Int
+	EventSupply *big.Int
+	Difference  *big.Int
+}
+
+type BalanceMismatch struct {
+	Address common.Address
+	Indexed *big.Int
+	Actual  *big.Int
+}
+
+type SupplyConsistency struct {
+	Samples           []SupplySample
+	SamplesSkipped    int
+	NegativeBalances  int
+	BalancesSkipped   int
+	BalanceMismatches []BalanceMismatch
+	BalanceDriftPct   float64
+	Flags             []string
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func (t *ERC20) Name(opts *bind.CallOpts) (string, error) {
@@ -42,3 +43,12 @@ func (t *ERC20) TotalSupply(opts *bind.CallOpts) (*big.Int, error) {
 	}
 	return result[0].(*big.Int), nil
 }
+
+func (t *ERC20) BalanceOf(opts *bind.CallOpts, account common.Address) (*big.Int, error) {
+	var result []interface{}
+	err := t.Contract.Call(opts, &result, "balanceOf", account)
+	if err != nil {
+		return nil, fmt.Errorf("\nContract call failed to balanceOf(): %s", err.Error())
+	}
+	return result[0].(*big.Int), nil
+}
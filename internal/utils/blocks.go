@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// SearchBlocks returns the first block in [lo, hi] for which pred holds,
+// assuming pred is false up to some block and true from then on. hi+1 is
+// returned when pred never holds.
+func SearchBlocks(lo, hi uint64, pred func(blockNumber uint64) (bool, error)) (uint64, error) {
+	end := hi + 1
+	for lo < end {
+		mid := lo + (end-lo)/2
+		ok, err := pred(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			end = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
+
+// FindCreationBlock binary searches for the block a contract's code first appears in.
+// Reading code at historical blocks requires an archive node.
+func FindCreationBlock(cl *ethclient.Client, address common.Address) (uint64, error) {
+	blockNum, err := cl.BlockNumber(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("\nFailed to get block number: %v", err)
+	}
+
+	creationBlock, err := SearchBlocks(0, blockNum, func(blockNumber uint64) (bool, error) {
+		code, err := cl.CodeAt(context.Background(), address, new(big.Int).SetUint64(blockNumber))
+		if err != nil {
+			return false, fmt.Errorf("\nFailed to get code at block %d: %v", blockNumber, err)
+		}
+		return len(code) > 0, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if creationBlock > blockNum {
+		return 0, fmt.Errorf("\nNo code found:\n\tAddress: %s", address)
+	}
+	return creationBlock, nil
+}
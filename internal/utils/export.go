@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// openOutput returns stdout when path is empty or "-", otherwise the created file
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return os.Stdout, nil
+	}
+	return os.Create(path)
+}
+
+func WriteCSV(path string, header []string, rows [][]string) error {
+	out, err := openOutput(path)
+	if err != nil {
+		return fmt.Errorf("\nFailed to open %s: %v", path, err)
+	}
+	if out != os.Stdout {
+		defer out.Close()
+	}
+
+	w := csv.NewWriter(out)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+func WriteJSON(path string, v interface{}) error {
+	out, err := openOutput(path)
+	if err != nil {
+		return fmt.Errorf("\nFailed to open %s: %v", path, err)
+	}
+	if out != os.Stdout {
+		defer out.Close()
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Most providers cap eth_getLogs ranges, so queries are split into chunks of this many blocks
+const LogChunkSize = 2000
+
+func FilterLogs(cl *ethclient.Client, addresses []common.Address, topics [][]common.Hash, fromBlock, toBlock uint64) ([]gethtypes.Log, error) {
+	var logs []gethtypes.Log
+	for start := fromBlock; start <= toBlock; start += LogChunkSize {
+		end := start + LogChunkSize - 1
+		if end > toBlock {
+			end = toBlock
+		}
+		query := ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: addresses,
+			Topics:    topics,
+		}
+		chunk, err := cl.FilterLogs(context.Background(), query)
+		if err != nil {
+			return nil, fmt.Errorf("\nFailed to filter logs in blocks %d -> %d: %v", start, end, err)
+		}
+		logs = append(logs, chunk...)
+	}
+	return logs, nil
+}
+
+// GetBlockTime returns the timestamp of the given block, using cache to avoid
+// refetching headers for blocks that contain several logs
+func GetBlockTime(cl *ethclient.Client, blockNumber uint64, cache map[uint64]time.Time) (time.Time, error) {
+	if timestamp, ok := cache[blockNumber]; ok {
+		return timestamp, nil
+	}
+	header, err := cl.HeaderByNumber(context.Background(), new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("\nFailed to get header for block %d: %v", blockNumber, err)
+	}
+	timestamp := time.Unix(int64(header.Time), 0).UTC()
+	if cache != nil {
+		cache[blockNumber] = timestamp
+	}
+	return timestamp, nil
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// PlotSeries renders values as an ASCII line chart of the given size, resampling
+// the series to the chart width
+func PlotSeries(title string, values []float64, width, height int) string {
+	if len(values) == 0 || width <= 0 || height <= 0 {
+		return title + ": no data\n"
+	}
+
+	columns := values
+	if len(values) > width {
+		columns = make([]float64, width)
+		for i := range columns {
+			columns[i] = values[i*len(values)/width]
+		}
+	}
+
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range columns {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	span := max - min
+	if span == 0 {
+		span = 1
+	}
+
+	grid := make([][]byte, height)
+	for row := range grid {
+		grid[row] = []byte(strings.Repeat(" ", len(columns)))
+	}
+	for col, v := range columns {
+		row := height - 1 - int(math.Round((v-min)/span*float64(height-1)))
+		grid[row][col] = '*'
+	}
+
+	var sb strings.Builder
+	sb.WriteString(title + "\n")
+	for row, line := range grid {
+		label := max - span*float64(row)/float64(maxInt(height-1, 1))
+		sb.WriteString(fmt.Sprintf("%14.6g |%s\n", label, line))
+	}
+	sb.WriteString(fmt.Sprintf("%14s +%s\n", "", strings.Repeat("-", len(columns))))
+	return sb.String()
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"context"
+	"fmt"
 	"math"
 	"math/big"
 	"strings"
@@ -8,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
@@ -39,4 +42,22 @@ func CalculateTotalSupply(totalSupply *big.Int, decimals uint8) (*big.Int) {
 	actualTotalSupply := new(big.Int)
 	actualTotalSupply, _ = actualTotalSupplyFloat.Int(actualTotalSupply)
 	return actualTotalSupply
-}
\ No newline at end of file
+}
+// ToDecimal scales a raw token amount down by the token's decimals
+func ToDecimal(amount *big.Int, decimals uint8) *big.Float {
+	factor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	return new(big.Float).Quo(new(big.Float).SetInt(amount), new(big.Float).SetInt(factor))
+}
+
+func GetTransactionSender(cl *ethclient.Client, txHash common.Hash) (common.Address, error) {
+	tx, _, err := cl.TransactionByHash(context.Background(), txHash)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("\nFailed to get transaction %s: %v", txHash, err)
+	}
+	signer := gethtypes.LatestSignerForChainID(tx.ChainId())
+	sender, err := signer.Sender(tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("\nFailed to get the signer of %s: %v", txHash, err)
+	}
+	return sender, nil
+}